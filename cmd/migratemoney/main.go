@@ -0,0 +1,151 @@
+// Command migratemoney converts amounts stored as bare numbers (product
+// prices, cart, order and delivery totals, delivery zone fees) into money
+// documents in the store currency. Documents that were already converted are
+// left alone, so it is safe to run more than once.
+//
+//	APP_ENV=dev go run ./cmd/migratemoney -dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// moneyFields lists the amount fields of each collection; a dotted path
+// descends into every element of an array
+var moneyFields = map[string][]string{
+	"products":       {"price", "variants.price"},
+	"carts":          {"sub_total", "total", "delivery_fee", "items.total"},
+	"orders":         {"sub_total", "delivery_fee", "total", "items.unit_price", "items.total"},
+	"delivery":       {"delivery_fee"},
+	"delivery_zones": {"base_fee", "free_shipping_threshold", "fee_tiers.per_km"},
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report what would change without writing")
+	flag.Parse()
+
+	common.LoadConfig()
+	db := common.ConnectDB(common.AppConfig.MongoURI, "shop-online")
+	currency := common.AppConfig.StoreCurrency
+	ctx := context.Background()
+
+	for name, paths := range moneyFields {
+		collection := db.Collection(name)
+
+		// Only fetch documents with at least one amount still stored as a number
+		filter := bson.A{}
+		for _, path := range paths {
+			filter = append(filter, bson.M{path: bson.M{"$type": "number"}})
+		}
+		cursor, err := collection.Find(ctx, bson.M{"$or": filter})
+		if err != nil {
+			log.Fatalf("Failed to read %s: %v", name, err)
+		}
+
+		converted := 0
+		for cursor.Next(ctx) {
+			var doc bson.M
+			if err := cursor.Decode(&doc); err != nil {
+				log.Fatalf("Failed to decode a document of %s: %v", name, err)
+			}
+
+			set := bson.M{}
+			for _, path := range paths {
+				field, rest := splitPath(path)
+				if value, changed := convert(doc[field], rest, currency); changed {
+					set[field] = value
+					doc[field] = value
+				}
+			}
+			if len(set) == 0 {
+				continue
+			}
+
+			converted++
+			if *dryRun {
+				continue
+			}
+			if _, err := collection.UpdateOne(ctx, bson.M{"_id": doc["_id"]}, bson.M{"$set": set}); err != nil {
+				log.Fatalf("Failed to update %s %v: %v", name, doc["_id"], err)
+			}
+		}
+		if err := cursor.Err(); err != nil {
+			log.Fatalf("Failed to read %s: %v", name, err)
+		}
+		cursor.Close(ctx)
+
+		if *dryRun {
+			log.Printf("%s: %d documents would be converted", name, converted)
+		} else {
+			log.Printf("%s: %d documents converted", name, converted)
+		}
+	}
+}
+
+// convert replaces the number at path inside value with a money document
+func convert(value interface{}, path []string, currency string) (interface{}, bool) {
+	if len(path) == 0 {
+		amount, ok := toFloat(value)
+		if !ok {
+			return value, false
+		}
+		return models.MoneyFromFloat(amount, currency), true
+	}
+
+	switch typed := value.(type) {
+	case bson.A:
+		changed := false
+		for i, element := range typed {
+			if next, ok := convert(element, path, currency); ok {
+				typed[i], changed = next, true
+			}
+		}
+		return typed, changed
+	case bson.M:
+		field, rest := path[0], path[1:]
+		next, ok := convert(typed[field], rest, currency)
+		if ok {
+			typed[field] = next
+		}
+		return typed, ok
+	case primitive.D:
+		for i, element := range typed {
+			if element.Key == path[0] {
+				next, ok := convert(element.Value, path[1:], currency)
+				if ok {
+					typed[i].Value = next
+				}
+				return typed, ok
+			}
+		}
+	}
+	return value, false
+}
+
+// toFloat reads a numeric BSON value
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, !math.IsNaN(number) && !math.IsInf(number, 0)
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	}
+	return 0, false
+}
+
+// splitPath splits a dotted field path into its first field and the rest
+func splitPath(path string) (string, []string) {
+	parts := strings.Split(path, ".")
+	return parts[0], parts[1:]
+}
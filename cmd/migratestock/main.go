@@ -0,0 +1,83 @@
+// Command migratestock gives products created before stock was tracked a
+// stock quantity. Until they have one, checkout can't reserve them and the
+// server refuses to start. Products that already have a stock quantity are
+// left alone, so it is safe to run more than once.
+//
+//	APP_ENV=dev go run ./cmd/migratestock -stock 100 -dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func main() {
+	stock := flag.Int("stock", -1, "stock quantity to give every product without one")
+	dryRun := flag.Bool("dry-run", false, "report what would change without writing")
+	flag.Parse()
+
+	if *stock < 0 {
+		log.Fatal("-stock is required and must not be negative")
+	}
+
+	common.LoadConfig()
+	db := common.ConnectDB(common.AppConfig.MongoURI, "shop-online")
+	products := db.Collection("products")
+	movements := db.Collection("stock_movements")
+	ctx := context.Background()
+
+	cursor, err := products.Find(ctx, common.MissingStockFilter())
+	if err != nil {
+		log.Fatalf("Failed to read products: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	updated := 0
+	for cursor.Next(ctx) {
+		var product models.Product
+		if err := cursor.Decode(&product); err != nil {
+			log.Fatalf("Failed to decode a product: %v", err)
+		}
+
+		updated++
+		if *dryRun {
+			continue
+		}
+
+		// Matching on the missing field keeps a concurrent stock change
+		result, err := products.UpdateOne(ctx, bson.M{"_id": product.ID, "stock": nil}, bson.M{"$set": bson.M{"stock": *stock}})
+		if err != nil {
+			log.Fatalf("Failed to update product %s: %v", product.ID.Hex(), err)
+		}
+		if result.ModifiedCount == 0 || *stock == 0 {
+			continue
+		}
+		_, err = movements.InsertOne(ctx, models.StockMovement{
+			ID:        primitive.NewObjectID(),
+			ProductID: product.ID,
+			Delta:     *stock,
+			Reason:    models.StockReasonCorrection,
+			Note:      "Initial stock set by migratestock",
+			CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+		})
+		if err != nil {
+			log.Fatalf("Failed to record the stock movement of product %s: %v", product.ID.Hex(), err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Fatalf("Failed to read products: %v", err)
+	}
+
+	if *dryRun {
+		log.Printf("products: %d would be given a stock of %d", updated, *stock)
+	} else {
+		log.Printf("products: %d given a stock of %d", updated, *stock)
+	}
+}
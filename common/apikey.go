@@ -0,0 +1,157 @@
+package common
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, revoked or expired API keys
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrAPIKeyIPNotAllowed is returned when a key is used outside its IP allowlist
+	ErrAPIKeyIPNotAllowed = errors.New("API key is not allowed from this IP address")
+)
+
+// apiKeyLastUsedInterval limits how often a key's last-used time is written
+const apiKeyLastUsedInterval = time.Minute
+
+// apiKeyPrefixEncoding encodes key prefixes without ambiguous characters or padding
+var apiKeyPrefixEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateAPIKey returns a new "sk_<prefix>_<secret>" key with its prefix and hash
+func GenerateAPIKey() (key string, prefix string, hash string, err error) {
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	prefix = strings.ToLower(apiKeyPrefixEncoding.EncodeToString(buf))
+
+	secret, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key = "sk_" + prefix + "_" + secret
+	return key, prefix, HashToken(key), nil
+}
+
+// ValidateAllowedIPs checks that every allowlist entry is an IP or CIDR range
+func ValidateAllowedIPs(allowedIPs []string) error {
+	for _, entry := range allowedIPs {
+		if net.ParseIP(entry) == nil {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return fmt.Errorf("invalid IP address or CIDR range: %s", entry)
+			}
+		}
+	}
+	return nil
+}
+
+// apiKeyFromRequest returns the API key sent in X-API-Key or as
+// "Authorization: ApiKey <key>", if any
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "ApiKey ") {
+		return strings.TrimSpace(strings.TrimPrefix(authHeader, "ApiKey "))
+	}
+	return ""
+}
+
+// authenticateAPIKey checks an API key and attaches it to the context. On
+// failure it responds and aborts the request.
+func authenticateAPIKey(c *gin.Context, rawKey string) (map[string]bool, bool) {
+	apiKey, err := verifyAPIKey(rawKey, c.ClientIP())
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, ErrAPIKeyIPNotAllowed) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"message": err.Error(), "status_code": status})
+		c.Abort()
+		return nil, false
+	}
+
+	granted := map[string]bool{}
+	for _, scope := range apiKey.Scopes {
+		granted[scope] = true
+	}
+	// Blocked scopes are dropped even if stored on the key
+	granted = withoutPermissions(granted, models.APIKeyBlockedScopes)
+
+	c.Set("apiKeyID", apiKey.ID.Hex())
+	c.Set("role", models.APIKeyActorRole)
+	return granted, true
+}
+
+// verifyAPIKey looks up a key by its prefix and checks its hash, expiry and
+// IP allowlist, recording when it was last used
+func verifyAPIKey(rawKey string, ip string) (models.APIKey, error) {
+	parts := strings.SplitN(rawKey, "_", 3)
+	if len(parts) != 3 || parts[0] != "sk" {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+
+	collection, ctx := GetCollection("api_keys")
+	defer ctx.Done()
+
+	var apiKey models.APIKey
+	if err := collection.FindOne(ctx, bson.M{"prefix": parts[1], "revoked_at": bson.M{"$exists": false}}).Decode(&apiKey); err != nil {
+		return apiKey, ErrInvalidAPIKey
+	}
+
+	if subtle.ConstantTimeCompare([]byte(HashToken(rawKey)), []byte(apiKey.KeyHash)) != 1 {
+		return apiKey, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if apiKey.ExpiresAt != 0 && apiKey.ExpiresAt.Time().Before(now) {
+		return apiKey, ErrInvalidAPIKey
+	}
+
+	if !ipAllowed(ip, apiKey.AllowedIPs) {
+		return apiKey, ErrAPIKeyIPNotAllowed
+	}
+
+	if now.Sub(apiKey.LastUsedAt.Time()) > apiKeyLastUsedInterval {
+		collection.UpdateOne(ctx, bson.M{"_id": apiKey.ID}, bson.M{"$set": bson.M{
+			"last_used_at": primitive.NewDateTimeFromTime(now),
+			"last_used_ip": ip,
+		}})
+	}
+	return apiKey, nil
+}
+
+// ipAllowed reports whether ip matches an allowlist entry; an empty list allows any
+func ipAllowed(ip string, allowedIPs []string) bool {
+	if len(allowedIPs) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, entry := range allowedIPs {
+		if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(parsed) {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,14 +1,14 @@
 package common
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -29,150 +29,200 @@ func ComparePasswords(hashedPassword, plainPassword string) bool {
 
 // Claims represents the structure of JWT claims
 type Claims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID    string `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
+	Purpose   string `json:"purpose,omitempty"` // Empty for access tokens
+
+	// Impersonator is the ID of the admin acting as the user, if any
+	Impersonator string `json:"impersonator,omitempty"`
+	jwt.StandardClaims
+}
+
+// PurposeClaims are the claims of single-purpose tokens such as email
+// verification links; they can never be used as access tokens
+type PurposeClaims struct {
+	UserID  string `json:"user_id"`
+	Purpose string `json:"purpose"`
 	jwt.StandardClaims
 }
 
-// GenerateToken generates a JWT token for the given user ID
-func GenerateToken(userID string, role string) (string, error) {
+// Token purposes
+const (
+	PurposeEmailVerification  = "email_verification"
+	PurposeTwoFactorChallenge = "two_factor_challenge"
+)
+
+// GenerateToken generates a short-lived access token for the given user session
+func GenerateToken(userID string, role string, sessionID string) (string, error) {
 	// Set expiration time
-	expirationTime := time.Now().Add(24 * time.Hour) // 24-hour token validity
+	now := time.Now()
+	expirationTime := now.Add(AppConfig.AccessTokenTTL)
 
 	// Create the claims
 	claims := &Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expirationTime.Unix(),
+			IssuedAt:  now.Unix(),
 		},
 	}
-	// Create the token with claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return signClaims(claims)
+}
 
-	var jwtSecret = []byte(AppConfig.JWTSecret)
-	// Sign the token with the secret
-	return token.SignedString(jwtSecret)
+// GenerateImpersonationToken generates an access token that lets an admin
+// act as the user within an impersonation session
+func GenerateImpersonationToken(userID string, role string, sessionID string, impersonatorID string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:       userID,
+		Role:         role,
+		SessionID:    sessionID,
+		Impersonator: impersonatorID,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: now.Add(ttl).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+	}
+	return signClaims(claims)
 }
 
 // ValidateToken validates the JWT token and returns the claims if valid
 func ValidateToken(tokenString string) (*Claims, error) {
-	var jwtSecret = []byte(AppConfig.JWTSecret)
-	// Parse the token
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
-	if err != nil {
+	claims := &Claims{}
+	if err := parseClaims(tokenString, claims); err != nil {
 		return nil, err
 	}
-	// Validate the token
-	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
-		return nil, err
+	if claims.Purpose != "" {
+		return nil, fmt.Errorf("token is not an access token")
 	}
-
 	return claims, nil
 }
 
-// AuthMiddleware validates the JWT token in requests
-func AuthMiddleware(allowedRoles ...string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Get the token from the Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Authorization header is required", "status_code": http.StatusUnauthorized})
-			c.Abort()
-			return
-		}
-
-		// Extract the token
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Token is required", "status_code": http.StatusUnauthorized})
-			c.Abort()
-			return
-		}
-
-		// Check if the token is blacklisted
-		if IsBlacklisted(tokenString) {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Token has been invalidated", "status_code": http.StatusUnauthorized})
-			c.Abort()
-			return
-		}
-
-		// Validate the token
-		claims, err := ValidateToken(tokenString)
-
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token", "status_code": http.StatusUnauthorized})
-			c.Abort()
-			return
-		}
-
-		objectID, err := ConvertIDMongodb(claims.UserID, c)
-
-		if err != nil {
-			// The message and response are already handled in ConvertIDMongodb
-			return
-		}
-
-		collection, ctx := GetCollection("users")
-
-		var user struct {
-			Token string `bson:"token"`
-		}
-
-		err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
-
-		if err != nil || user.Token != tokenString {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token", "status_code": http.StatusUnauthorized})
-			c.Abort()
-			return
-		}
-		// Check if the user's role is allowed
-		allowed := false
-		for _, role := range allowedRoles {
-			if claims.Role == role {
-				allowed = true
-				break
-			}
-		}
-
-		if !allowed {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Your role does not have access.", "status_code": http.StatusUnauthorized})
-			c.Abort()
-			return
-		}
-
-		// Attach claims to the context
-		c.Set("userID", claims.UserID)
-
-		// Proceed to the next handler
-		c.Next()
+// GeneratePurposeToken signs a token usable only for the given purpose.
+// The subject binds the token to a value such as the email being verified.
+func GeneratePurposeToken(userID string, purpose string, subject string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &PurposeClaims{
+		UserID:  userID,
+		Purpose: purpose,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   subject,
+			ExpiresAt: now.Add(ttl).Unix(),
+			IssuedAt:  now.Unix(),
+		},
 	}
+	return signClaims(claims)
 }
 
-var TokenBlacklist = struct {
-	sync.RWMutex
-	tokens map[string]time.Time
-}{
-	tokens: make(map[string]time.Time),
+// ValidatePurposeToken validates a single-purpose token and returns its claims
+func ValidatePurposeToken(tokenString string, purpose string) (*PurposeClaims, error) {
+	claims := &PurposeClaims{}
+	if err := parseClaims(tokenString, claims); err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, fmt.Errorf("token is not valid for %s", purpose)
+	}
+	return claims, nil
 }
 
-// AddToBlacklist adds a token to the blacklist with an expiration time
-func AddToBlacklist(token string, expiration time.Time) {
-	TokenBlacklist.Lock()
-	defer TokenBlacklist.Unlock()
-	TokenBlacklist.tokens[token] = expiration
+// signClaims signs JWT claims with the current signing key
+func signClaims(claims jwt.Claims) (string, error) {
+	return JWTKeys.Sign(claims)
 }
 
-// IsBlacklisted checks if a token is in the blacklist
-func IsBlacklisted(token string) bool {
-	TokenBlacklist.RLock()
-	defer TokenBlacklist.RUnlock()
-	expiration, exists := TokenBlacklist.tokens[token]
-	if !exists {
-		return false
+// parseClaims verifies a JWT against the key named by its kid and decodes it into claims
+func parseClaims(tokenString string, claims jwt.Claims) error {
+	// Parse the token
+	token, err := jwt.ParseWithClaims(tokenString, claims, JWTKeys.Keyfunc)
+	if err != nil {
+		return err
 	}
-	return time.Now().Before(expiration)
+	// Validate the token
+	if !token.Valid {
+		return fmt.Errorf("invalid token")
+	}
+	return nil
+}
+
+// authenticate identifies the caller of a request by API key or bearer token
+// and returns the permissions granted to them. On failure it responds and
+// aborts the request.
+func authenticate(c *gin.Context) (map[string]bool, bool) {
+	if apiKey := apiKeyFromRequest(c); apiKey != "" {
+		return authenticateAPIKey(c, apiKey)
+	}
+
+	claims, ok := authenticateBearer(c)
+	if !ok {
+		return nil, false
+	}
+
+	granted, err := PermissionsForRole(claims.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to load permissions", "status_code": http.StatusInternalServerError})
+		c.Abort()
+		return nil, false
+	}
+
+	if claims.Impersonator != "" {
+		granted = withoutPermissions(granted, models.ImpersonationBlockedPermissions)
+	}
+	return granted, true
+}
+
+// authenticateBearer validates the bearer token of a request and attaches
+// the user to the context. On failure it responds and aborts the request.
+func authenticateBearer(c *gin.Context) (*Claims, bool) {
+	// Get the token from the Authorization header
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Authorization header is required", "status_code": http.StatusUnauthorized})
+		c.Abort()
+		return nil, false
+	}
+
+	// Extract the token
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Token is required", "status_code": http.StatusUnauthorized})
+		c.Abort()
+		return nil, false
+	}
+
+	// Check if the token is blacklisted
+	if IsBlacklisted(tokenString) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Token has been invalidated", "status_code": http.StatusUnauthorized})
+		c.Abort()
+		return nil, false
+	}
+
+	// Validate the token
+	claims, err := ValidateToken(tokenString)
+
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token", "status_code": http.StatusUnauthorized})
+		c.Abort()
+		return nil, false
+	}
+
+	// Check that the session behind the token hasn't been revoked
+	if !isSessionActive(claims.SessionID, claims.UserID, claims.Role) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token", "status_code": http.StatusUnauthorized})
+		c.Abort()
+		return nil, false
+	}
+
+	// Attach claims to the context
+	c.Set("userID", claims.UserID)
+	c.Set("role", claims.Role)
+	c.Set("sessionID", claims.SessionID)
+	if claims.Impersonator != "" {
+		c.Set("impersonatorID", claims.Impersonator)
+	}
+
+	return claims, true
 }
@@ -0,0 +1,148 @@
+package common
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TokenRevocationStore keeps revoked access tokens until they expire
+type TokenRevocationStore interface {
+	// Add revokes a token until its expiration time
+	Add(token string, expiration time.Time) error
+	// IsRevoked reports whether a token has been revoked and not yet expired
+	IsRevoked(token string) (bool, error)
+	// Sweep removes entries whose tokens have expired
+	Sweep() error
+}
+
+// TokenBlacklist is the revocation store used by RequirePermission and Logout
+var TokenBlacklist TokenRevocationStore = NewMemoryTokenBlacklist()
+
+// InitTokenBlacklist selects the revocation store from the app configuration
+func InitTokenBlacklist() {
+	switch AppConfig.TokenBlacklistStore {
+	case "memory":
+		TokenBlacklist = NewMemoryTokenBlacklist()
+	case "", "mongo":
+		TokenBlacklist = NewMongoTokenBlacklist("token_blacklist")
+	default:
+		log.Fatalf("Unknown TOKEN_BLACKLIST_STORE: %s", AppConfig.TokenBlacklistStore)
+	}
+}
+
+// StartBlacklistSweeper periodically removes expired blacklist entries
+func StartBlacklistSweeper(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := TokenBlacklist.Sweep(); err != nil {
+				log.Println("Failed to sweep token blacklist:", err)
+			}
+		}
+	}()
+}
+
+// AddToBlacklist adds a token to the blacklist with an expiration time
+func AddToBlacklist(token string, expiration time.Time) error {
+	return TokenBlacklist.Add(token, expiration)
+}
+
+// IsBlacklisted checks if a token is in the blacklist. Tokens are treated
+// as revoked when the store can't be reached.
+func IsBlacklisted(token string) bool {
+	revoked, err := TokenBlacklist.IsRevoked(token)
+	if err != nil {
+		log.Println("Failed to check token blacklist:", err)
+		return true
+	}
+	return revoked
+}
+
+// MemoryTokenBlacklist is an in-process revocation store for tests and single-instance use
+type MemoryTokenBlacklist struct {
+	sync.RWMutex
+	tokens map[string]time.Time
+}
+
+// NewMemoryTokenBlacklist creates an empty in-memory revocation store
+func NewMemoryTokenBlacklist() *MemoryTokenBlacklist {
+	return &MemoryTokenBlacklist{tokens: make(map[string]time.Time)}
+}
+
+func (b *MemoryTokenBlacklist) Add(token string, expiration time.Time) error {
+	b.Lock()
+	defer b.Unlock()
+	b.tokens[HashToken(token)] = expiration
+	return nil
+}
+
+func (b *MemoryTokenBlacklist) IsRevoked(token string) (bool, error) {
+	b.RLock()
+	defer b.RUnlock()
+	expiration, exists := b.tokens[HashToken(token)]
+	if !exists {
+		return false, nil
+	}
+	return time.Now().Before(expiration), nil
+}
+
+func (b *MemoryTokenBlacklist) Sweep() error {
+	b.Lock()
+	defer b.Unlock()
+	now := time.Now()
+	for token, expiration := range b.tokens {
+		if !now.Before(expiration) {
+			delete(b.tokens, token)
+		}
+	}
+	return nil
+}
+
+// MongoTokenBlacklist is a revocation store shared by every replica. Entries
+// are keyed by token hash and removed by a TTL index once they expire.
+type MongoTokenBlacklist struct {
+	collection string
+}
+
+// NewMongoTokenBlacklist creates a revocation store backed by a MongoDB collection
+func NewMongoTokenBlacklist(collection string) *MongoTokenBlacklist {
+	return &MongoTokenBlacklist{collection: collection}
+}
+
+func (b *MongoTokenBlacklist) Add(token string, expiration time.Time) error {
+	collection, ctx := GetCollection(b.collection)
+	defer ctx.Done()
+
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": HashToken(token)},
+		bson.M{"$set": bson.M{"expires_at": primitive.NewDateTimeFromTime(expiration)}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (b *MongoTokenBlacklist) IsRevoked(token string) (bool, error) {
+	collection, ctx := GetCollection(b.collection)
+	defer ctx.Done()
+
+	count, err := collection.CountDocuments(ctx, bson.M{
+		"_id":        HashToken(token),
+		"expires_at": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+	})
+	return count > 0, err
+}
+
+// Sweep deletes expired entries between runs of MongoDB's TTL monitor
+func (b *MongoTokenBlacklist) Sweep() error {
+	collection, ctx := GetCollection(b.collection)
+	defer ctx.Done()
+
+	_, err := collection.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lte": primitive.NewDateTimeFromTime(time.Now())}})
+	return err
+}
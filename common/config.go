@@ -3,15 +3,50 @@ package common
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
 )
 
 type Config struct {
-	AppEnv    string
-	MongoURI  string
-	JWTSecret string
-	PORT      string
+	AppEnv                string
+	MongoURI              string
+	JWTKeyDir             string
+	JWTSigningKID         string
+	PORT                  string
+	TrustedProxies        []string // Proxies whose X-Forwarded-For is believed; none when empty
+	AccessTokenTTL        time.Duration
+	RefreshTokenTTL       time.Duration
+	TokenBlacklistStore   string
+	BlacklistSweepEvery   time.Duration
+	AppBaseURL            string
+	Mailer                string
+	MailLogFile           string
+	EmailVerificationTTL  time.Duration
+	PasswordResetTTL      time.Duration
+	RequireAdmin2FA       bool
+	TOTPIssuer            string
+	LoginMaxAttempts      int
+	LoginIPMaxAttempts    int
+	LoginAttemptWindow    time.Duration
+	LoginLockoutBase      time.Duration
+	LoginLockoutMax       time.Duration
+	OIDCProviders         map[string]*OIDCProvider
+	OIDCFrontendURL       string // Frontend page provider sign ins end at, with a login code or an error
+	PermissionCacheTTL    time.Duration
+	ImpersonationTTL      time.Duration
+	StoreLatitude         float64
+	StoreLongitude        float64
+	StoreCurrency         string // ISO currency code of catalog prices and totals
+	DeliveryBaseFee       models.Money
+	DeliveryFeeTiers      []models.FeeTier
+	FreeShippingThreshold models.Money
+	PricesIncludeTax      bool   // Catalog prices and delivery fees already include tax
+	DefaultTaxClass       string // Tax class code of products whose category has none
+	DeliveryTaxClass      string // Tax class code of the delivery fee, or DeliveryTaxProportional
 }
 
 var AppConfig *Config
@@ -28,10 +63,145 @@ func LoadConfig() {
 	}
 
 	// Initialize configuration
+	storeCurrency := getEnvString("STORE_CURRENCY", "THB")
+	if !models.IsValidCurrency(storeCurrency) {
+		log.Fatalf("Invalid value for STORE_CURRENCY: unsupported currency %q", storeCurrency)
+	}
+	models.DefaultCurrency = storeCurrency
+
+	deliveryFeeTiers, err := ParseFeeTiers(os.Getenv("DELIVERY_FEE_TIERS"), storeCurrency)
+	if err != nil {
+		log.Fatalf("Invalid value for DELIVERY_FEE_TIERS: %v", err)
+	}
+
+	oidcProviders, err := LoadOIDCProviders(os.Getenv("OIDC_PROVIDERS"))
+	if err != nil {
+		log.Fatalf("Invalid OpenID Connect configuration: %v", err)
+	}
+	oidcFrontendURL := os.Getenv("OIDC_FRONTEND_REDIRECT_URL")
+	if len(oidcProviders) > 0 && oidcFrontendURL == "" {
+		log.Fatalf("Invalid OpenID Connect configuration: OIDC_FRONTEND_REDIRECT_URL is required")
+	}
+
 	AppConfig = &Config{
-		AppEnv:    os.Getenv("APP_ENV"),
-		MongoURI:  os.Getenv("MONGO_URI"),
-		JWTSecret: os.Getenv("JWT_SECRET"),
-		PORT:      os.Getenv("PORT"),
+		AppEnv:                os.Getenv("APP_ENV"),
+		MongoURI:              os.Getenv("MONGO_URI"),
+		JWTKeyDir:             os.Getenv("JWT_KEY_DIR"),
+		JWTSigningKID:         os.Getenv("JWT_SIGNING_KID"),
+		PORT:                  os.Getenv("PORT"),
+		TrustedProxies:        getEnvList("TRUSTED_PROXIES"),
+		AccessTokenTTL:        getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:       getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		TokenBlacklistStore:   os.Getenv("TOKEN_BLACKLIST_STORE"),
+		BlacklistSweepEvery:   getEnvDuration("BLACKLIST_SWEEP_INTERVAL", 10*time.Minute),
+		AppBaseURL:            os.Getenv("APP_BASE_URL"),
+		Mailer:                os.Getenv("MAILER"),
+		MailLogFile:           os.Getenv("MAIL_LOG_FILE"),
+		EmailVerificationTTL:  getEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		PasswordResetTTL:      getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+		RequireAdmin2FA:       getEnvBool("REQUIRE_ADMIN_2FA", false),
+		TOTPIssuer:            getEnvString("TOTP_ISSUER", "Shop Online"),
+		LoginMaxAttempts:      getEnvInt("LOGIN_MAX_ATTEMPTS", 5),
+		LoginIPMaxAttempts:    getEnvInt("LOGIN_IP_MAX_ATTEMPTS", 20),
+		LoginAttemptWindow:    getEnvDuration("LOGIN_ATTEMPT_WINDOW", 15*time.Minute),
+		LoginLockoutBase:      getEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute),
+		LoginLockoutMax:       getEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour),
+		OIDCProviders:         oidcProviders,
+		OIDCFrontendURL:       oidcFrontendURL,
+		PermissionCacheTTL:    getEnvDuration("PERMISSION_CACHE_TTL", time.Minute),
+		ImpersonationTTL:      getEnvDuration("IMPERSONATION_TTL", 15*time.Minute),
+		StoreLatitude:         getEnvFloat("STORE_LATITUDE", 0),
+		StoreLongitude:        getEnvFloat("STORE_LONGITUDE", 0),
+		StoreCurrency:         storeCurrency,
+		DeliveryBaseFee:       getEnvMoney("DELIVERY_BASE_FEE", storeCurrency),
+		DeliveryFeeTiers:      deliveryFeeTiers,
+		FreeShippingThreshold: getEnvMoney("FREE_SHIPPING_THRESHOLD", storeCurrency),
+		PricesIncludeTax:      getEnvBool("PRICES_INCLUDE_TAX", true),
+		DefaultTaxClass:       getEnvString("DEFAULT_TAX_CLASS", models.TaxClassVAT7),
+		DeliveryTaxClass:      getEnvString("DELIVERY_TAX_CLASS", models.TaxClassVAT7),
+	}
+}
+
+// getEnvFloat reads a float environment variable or returns the fallback
+func getEnvFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Fatalf("Invalid value for %s: %v", key, err)
+	}
+	return parsed
+}
+
+// getEnvMoney reads a decimal amount environment variable (e.g. "20.50") or returns zero
+func getEnvMoney(key string, currency string) models.Money {
+	value := os.Getenv(key)
+	if value == "" {
+		return models.NewMoney(0, currency)
+	}
+	parsed, err := models.ParseMoney(value, currency)
+	if err != nil {
+		log.Fatalf("Invalid value for %s: %v", key, err)
+	}
+	return parsed
+}
+
+// getEnvInt reads an integer environment variable or returns the fallback
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid value for %s: %v", key, err)
+	}
+	return parsed
+}
+
+// getEnvDuration reads a duration environment variable (e.g. "15m") or returns the fallback
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid value for %s: %v", key, err)
+	}
+	return parsed
+}
+
+// getEnvBool reads a boolean environment variable or returns the fallback
+func getEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid value for %s: %v", key, err)
+	}
+	return parsed
+}
+
+// getEnvList reads a comma-separated environment variable, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvString reads a string environment variable or returns the fallback
+func getEnvString(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
+	return fallback
 }
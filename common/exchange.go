@@ -0,0 +1,253 @@
+package common
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrUnsupportedCurrency is returned for currency codes we can't price in
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrNoExchangeRate is returned when no rate is in effect for a currency
+	ErrNoExchangeRate = errors.New("no exchange rate in effect for currency")
+)
+
+// exchangeRateDateLayout is the date format accepted for effective dates
+// besides RFC 3339 timestamps
+const exchangeRateDateLayout = "2006-01-02"
+
+// ParseExchangeRate parses a positive decimal rate such as "0.0285"
+func ParseExchangeRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || rate.Sign() <= 0 || strings.ContainsAny(value, "/eE") {
+		return nil, fmt.Errorf("invalid exchange rate %q", value)
+	}
+	return rate, nil
+}
+
+// ParseEffectiveDate parses a date ("2006-01-02", midnight UTC) or an RFC 3339 timestamp
+func ParseEffectiveDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if date, err := time.Parse(exchangeRateDateLayout, value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// NewExchangeRate validates a rate from the store currency into currency
+func NewExchangeRate(currency string, rate string, effectiveFrom time.Time, actorID primitive.ObjectID) (models.ExchangeRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !models.IsValidCurrency(currency) {
+		return models.ExchangeRate{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, currency)
+	}
+	if currency == AppConfig.StoreCurrency {
+		return models.ExchangeRate{}, fmt.Errorf("%s is the store currency and always converts at 1", currency)
+	}
+	parsed, err := ParseExchangeRate(rate)
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+
+	return models.ExchangeRate{
+		ID:            primitive.NewObjectID(),
+		Currency:      currency,
+		Rate:          parsed.FloatString(decimalPlaces(rate)),
+		EffectiveFrom: primitive.NewDateTimeFromTime(effectiveFrom),
+		CreatedBy:     actorID,
+		CreatedAt:     primitive.NewDateTimeFromTime(time.Now()),
+	}, nil
+}
+
+// ParseExchangeRatesCSV reads rates from a CSV file with a header row naming
+// the currency, rate and effective_from columns, in any order. Every row is
+// checked before any is returned.
+func ParseExchangeRatesCSV(r io.Reader, actorID primitive.ObjectID) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"currency", "rate", "effective_from"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header must contain the %s column", name)
+		}
+	}
+
+	rates := []models.ExchangeRate{}
+	seen := map[string]bool{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		effectiveFrom, err := ParseEffectiveDate(record[columns["effective_from"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid effective_from %q", line, record[columns["effective_from"]])
+		}
+		rate, err := NewExchangeRate(record[columns["currency"]], record[columns["rate"]], effectiveFrom, actorID)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		key := rate.Currency + "@" + effectiveFrom.UTC().Format(time.RFC3339)
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: %s already has a rate from %s", line, rate.Currency, effectiveFrom.UTC().Format(time.RFC3339))
+		}
+		seen[key] = true
+		rates = append(rates, rate)
+	}
+
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("CSV file contains no rates")
+	}
+	return rates, nil
+}
+
+// SaveExchangeRates stores rates, replacing any rate of the same currency
+// and effective date so a corrected file can be imported again
+func SaveExchangeRates(rates []models.ExchangeRate) error {
+	collection, ctx := GetCollection("exchange_rates")
+	defer ctx.Done()
+
+	writes := make([]mongo.WriteModel, 0, len(rates))
+	for _, rate := range rates {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"currency": rate.Currency, "effective_from": rate.EffectiveFrom}).
+			SetUpdate(bson.M{
+				"$set":         bson.M{"rate": rate.Rate, "created_by": rate.CreatedBy, "created_at": rate.CreatedAt},
+				"$setOnInsert": bson.M{"_id": rate.ID},
+			}).
+			SetUpsert(true))
+	}
+
+	_, err := collection.BulkWrite(ctx, writes)
+	return err
+}
+
+// FindExchangeRate returns the rate for a currency in effect at a time. The
+// store currency always converts at 1.
+func FindExchangeRate(currency string, at time.Time) (models.ExchangeRate, error) {
+	currency = strings.ToUpper(currency)
+	if !models.IsValidCurrency(currency) {
+		return models.ExchangeRate{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, currency)
+	}
+	if currency == AppConfig.StoreCurrency {
+		return models.ExchangeRate{Currency: currency, Rate: "1"}, nil
+	}
+
+	collection, ctx := GetCollection("exchange_rates")
+	defer ctx.Done()
+
+	var rate models.ExchangeRate
+	err := collection.FindOne(ctx,
+		bson.M{"currency": currency, "effective_from": bson.M{"$lte": primitive.NewDateTimeFromTime(at)}},
+		options.FindOne().SetSort(bson.M{"effective_from": -1}),
+	).Decode(&rate)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return rate, fmt.Errorf("%w %s", ErrNoExchangeRate, currency)
+	}
+	return rate, err
+}
+
+// ExchangeRateFromQuery returns the rate in effect now for the "currency"
+// query parameter, or nil when none was requested. On failure it responds
+// and returns false.
+func ExchangeRateFromQuery(c *gin.Context) (*models.ExchangeRate, bool) {
+	currency := c.Query("currency")
+	if currency == "" {
+		return nil, true
+	}
+
+	rate, err := FindExchangeRate(currency, time.Now())
+	switch {
+	case errors.Is(err, ErrUnsupportedCurrency):
+		RespondWithError(c, http.StatusBadRequest, "Unsupported currency", err)
+		return nil, false
+	case errors.Is(err, ErrNoExchangeRate):
+		RespondWithError(c, http.StatusUnprocessableEntity, "No exchange rate is available for this currency", err)
+		return nil, false
+	case err != nil:
+		RespondWithError(c, http.StatusInternalServerError, "Failed to fetch exchange rate", err)
+		return nil, false
+	}
+	return &rate, true
+}
+
+// ConvertMoney converts a store currency amount with an exchange rate,
+// rounding to the minor unit of the target currency
+func ConvertMoney(amount models.Money, rate models.ExchangeRate) (models.Money, error) {
+	if amount.Currency == rate.Currency {
+		return amount, nil
+	}
+
+	factor, err := ParseExchangeRate(rate.Rate)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("invalid stored exchange rate for %s: %w", rate.Currency, err)
+	}
+
+	// Scale between the minor units of the two currencies, e.g. satang to yen
+	scale := big.NewRat(1, 1)
+	for shift := models.CurrencyExponent(rate.Currency) - models.CurrencyExponent(amount.Currency); shift != 0; {
+		if shift > 0 {
+			scale.Mul(scale, big.NewRat(10, 1))
+			shift--
+		} else {
+			scale.Quo(scale, big.NewRat(10, 1))
+			shift++
+		}
+	}
+
+	converted := amount.MulRat(factor.Mul(factor, scale))
+	converted.Currency = rate.Currency
+	return converted, nil
+}
+
+// ConvertStoredAmount converts an amount decoded into a bson.M, returning
+// false when the value is not an amount
+func ConvertStoredAmount(value interface{}, rate models.ExchangeRate) (models.Money, bool, error) {
+	if value == nil {
+		return models.Money{}, false, nil
+	}
+
+	raw, err := bson.Marshal(bson.M{"amount": value})
+	if err != nil {
+		return models.Money{}, false, nil
+	}
+	var holder struct {
+		Amount models.Money `bson:"amount"`
+	}
+	if err := bson.Unmarshal(raw, &holder); err != nil || holder.Amount.Currency == "" {
+		return models.Money{}, false, nil
+	}
+	converted, err := ConvertMoney(holder.Amount, rate)
+	return converted, err == nil, err
+}
+
+// decimalPlaces counts the digits after the decimal point of a number
+func decimalPlaces(value string) int {
+	_, fraction, _ := strings.Cut(strings.TrimSpace(value), ".")
+	return len(fraction)
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImpersonationToken is the access token of an impersonation session
+type ImpersonationToken struct {
+	AccessToken string `json:"token"`
+	ExpiresIn   int64  `json:"expires_in"` // Token lifetime in seconds
+	SessionID   string `json:"session_id"`
+}
+
+// StartImpersonation opens a short-lived session in which the admin acts as
+// the user. It has no refresh token, and its access token names the admin.
+func StartImpersonation(user models.User, adminID primitive.ObjectID, c *gin.Context) (ImpersonationToken, error) {
+	// Sessions need a unique refresh token hash; this one is never handed out
+	unusedRefreshToken, err := GenerateOpaqueToken()
+	if err != nil {
+		return ImpersonationToken{}, err
+	}
+
+	now := time.Now()
+	ttl := AppConfig.ImpersonationTTL
+	session := models.Session{
+		ID:                 primitive.NewObjectID(),
+		UserID:             user.ID,
+		RefreshTokenHash:   HashToken(unusedRefreshToken),
+		RotatedTokenHashes: []string{},
+		UserAgent:          c.Request.UserAgent(),
+		IP:                 c.ClientIP(),
+		CreatedAt:          primitive.NewDateTimeFromTime(now),
+		LastSeenAt:         primitive.NewDateTimeFromTime(now),
+		ExpiresAt:          primitive.NewDateTimeFromTime(now.Add(ttl)),
+		ImpersonatorID:     adminID,
+	}
+
+	collection, ctx := GetCollection("sessions")
+	defer ctx.Done()
+
+	if _, err := collection.InsertOne(ctx, session); err != nil {
+		return ImpersonationToken{}, err
+	}
+
+	accessToken, err := GenerateImpersonationToken(user.ID.Hex(), user.Role, session.ID.Hex(), adminID.Hex(), ttl)
+	if err != nil {
+		return ImpersonationToken{}, err
+	}
+
+	RecordAuditLog(c, models.AuditLog{Action: models.AuditImpersonationStarted, ActorID: adminID, UserID: user.ID, SessionID: session.ID})
+	return ImpersonationToken{AccessToken: accessToken, ExpiresIn: int64(ttl.Seconds()), SessionID: session.ID.Hex()}, nil
+}
+
+// RecordImpersonatedRequest writes a request made with an impersonation
+// token to the audit trail
+func RecordImpersonatedRequest(c *gin.Context) {
+	adminID, _ := primitive.ObjectIDFromHex(c.GetString("impersonatorID"))
+	userID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+	sessionID, _ := primitive.ObjectIDFromHex(c.GetString("sessionID"))
+
+	RecordAuditLog(c, models.AuditLog{
+		Action:     models.AuditImpersonatedRequest,
+		ActorID:    adminID,
+		UserID:     userID,
+		SessionID:  sessionID,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		StatusCode: c.Writer.Status(),
+	})
+}
+
+// RecordAuditLog appends an entry to the audit trail
+func RecordAuditLog(c *gin.Context, entry models.AuditLog) {
+	collection, ctx := GetCollection("audit_logs")
+	defer ctx.Done()
+
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	entry.IP = c.ClientIP()
+	entry.UserAgent = c.Request.UserAgent()
+	if _, err := collection.InsertOne(ctx, entry); err != nil {
+		log.Printf("Failed to record audit log %s: %v", entry.Action, err)
+	}
+}
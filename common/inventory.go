@@ -0,0 +1,189 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInsufficientStock is returned when a product does not have enough stock
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrProductNotFound is returned when the product to adjust does not exist
+	ErrProductNotFound = errors.New("product not found")
+	// ErrVariantNotFound is returned when a variant does not belong to the product
+	ErrVariantNotFound = errors.New("product variant not found")
+	// ErrVariantRequired is returned when a product with variants is used without choosing one
+	ErrVariantRequired = errors.New("product variant is required")
+)
+
+// StockLine is a quantity of a product, or of one of its variants, to
+// reserve or release
+type StockLine struct {
+	ProductID primitive.ObjectID
+	VariantID primitive.ObjectID // Zero for products without variants
+	Qty       int
+}
+
+// StockLinesFromOrderItems converts order items into stock lines
+func StockLinesFromOrderItems(items []models.OrderItem) []StockLine {
+	lines := make([]StockLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, StockLine{ProductID: item.ProductID, VariantID: item.VariantID, Qty: item.Qty})
+	}
+	return lines
+}
+
+// MissingStockFilter matches products created before stock was tracked,
+// which have no stock quantity for a reservation to match
+func MissingStockFilter() bson.M {
+	return bson.M{"stock": nil}
+}
+
+// CheckStockTracked stops startup while any product has no stock quantity,
+// rather than letting checkout reject it as out of stock
+func CheckStockTracked() {
+	collection, ctx := GetCollection("products")
+	defer ctx.Done()
+
+	count, err := collection.CountDocuments(ctx, MissingStockFilter())
+	if err != nil {
+		log.Fatalf("Failed to check product stock: %v", err)
+	}
+	if count > 0 {
+		log.Fatalf("%d products have no stock quantity and can't be sold; give them one with: go run ./cmd/migratestock -stock <quantity>", count)
+	}
+}
+
+// stockTarget returns the filter matching the stock counter of a product or
+// variant and the field to increment. A positive minimum only matches while
+// at least that much stock remains.
+func stockTarget(productID primitive.ObjectID, variantID primitive.ObjectID, minimum int) (bson.M, string) {
+	if variantID.IsZero() {
+		filter := bson.M{"_id": productID}
+		if minimum > 0 {
+			filter["stock"] = bson.M{"$gte": minimum}
+		}
+		return filter, "stock"
+	}
+
+	match := bson.M{"_id": variantID}
+	if minimum > 0 {
+		match["stock"] = bson.M{"$gte": minimum}
+	}
+	return bson.M{"_id": productID, "variants": bson.M{"$elemMatch": match}}, "variants.$.stock"
+}
+
+// ReserveStock atomically decrements stock for every line of an order.
+// Each decrement only applies while enough stock remains, so concurrent
+// checkouts can't push stock below zero. On failure, lines already
+// reserved are released again.
+func ReserveStock(orderID primitive.ObjectID, lines []StockLine) error {
+	collection, ctx := GetCollection("products")
+	defer ctx.Done()
+
+	for i, line := range lines {
+		filter, field := stockTarget(line.ProductID, line.VariantID, line.Qty)
+		result, err := collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{field: -line.Qty}})
+		if err == nil && result.ModifiedCount == 0 {
+			err = fmt.Errorf("%w for product %s", ErrInsufficientStock, line.ProductID.Hex())
+		}
+		if err != nil {
+			ReleaseStock(orderID, lines[:i])
+			return err
+		}
+		recordStockMovement(models.StockMovement{
+			ProductID: line.ProductID,
+			VariantID: line.VariantID,
+			Delta:     -line.Qty,
+			Reason:    models.StockReasonOrderPlaced,
+			OrderID:   orderID,
+		})
+	}
+	return nil
+}
+
+// ReleaseStock returns reserved stock for every line of an order
+func ReleaseStock(orderID primitive.ObjectID, lines []StockLine) error {
+	collection, ctx := GetCollection("products")
+	defer ctx.Done()
+
+	var firstErr error
+	for _, line := range lines {
+		filter, field := stockTarget(line.ProductID, line.VariantID, 0)
+		_, err := collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{field: line.Qty}})
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		recordStockMovement(models.StockMovement{
+			ProductID: line.ProductID,
+			VariantID: line.VariantID,
+			Delta:     line.Qty,
+			Reason:    models.StockReasonOrderReleased,
+			OrderID:   orderID,
+		})
+	}
+	return firstErr
+}
+
+// AdjustStock applies a manual stock change to a product, or to one of its
+// variants when variantID is set, and returns the new quantity
+func AdjustStock(productID primitive.ObjectID, variantID primitive.ObjectID, delta int, reason string, actorID primitive.ObjectID, note string) (int, error) {
+	collection, ctx := GetCollection("products")
+	defer ctx.Done()
+
+	var product models.Product
+	if err := collection.FindOne(ctx, bson.M{"_id": productID}).Decode(&product); err != nil {
+		return 0, ErrProductNotFound
+	}
+	if variantID.IsZero() && product.HasVariants() {
+		return 0, ErrVariantRequired
+	}
+	if _, ok := product.FindVariant(variantID); !variantID.IsZero() && !ok {
+		return 0, ErrVariantNotFound
+	}
+
+	filter, field := stockTarget(productID, variantID, -delta)
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{field: delta}})
+	if err != nil {
+		return 0, err
+	}
+	if result.MatchedCount == 0 {
+		return 0, ErrInsufficientStock
+	}
+
+	recordStockMovement(models.StockMovement{
+		ProductID: productID,
+		VariantID: variantID,
+		Delta:     delta,
+		Reason:    reason,
+		ActorID:   actorID,
+		Note:      note,
+	})
+
+	if err := collection.FindOne(ctx, bson.M{"_id": productID}).Decode(&product); err != nil {
+		return 0, err
+	}
+	if variant, ok := product.FindVariant(variantID); ok {
+		return variant.Stock, nil
+	}
+	return product.Stock, nil
+}
+
+// recordStockMovement appends an entry to the stock movement log
+func recordStockMovement(movement models.StockMovement) {
+	collection, ctx := GetCollection("stock_movements")
+	defer ctx.Done()
+
+	movement.ID = primitive.NewObjectID()
+	movement.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	collection.InsertOne(ctx, movement)
+}
@@ -0,0 +1,259 @@
+package common
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningMethodEdDSA signs tokens with Ed25519, which jwt-go v3 lacks
+var SigningMethodEdDSA = &signingMethodEd25519{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+// SigningKey is a key tokens are signed or verified with
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer // Nil for retired keys that only verify
+	PublicKey  crypto.PublicKey
+}
+
+// KeySet holds every key tokens may be verified with and the one new
+// tokens are signed with
+type KeySet struct {
+	keys    map[string]*SigningKey
+	signing *SigningKey
+}
+
+// JWTKeys is the application key set, loaded by InitJWTKeys
+var JWTKeys *KeySet
+
+// InitJWTKeys loads the signing keys from AppConfig.JWTKeyDir. Each *.pem
+// file is one key and its name without the extension is its kid. Private
+// keys can sign and verify; public keys only verify tokens signed before a
+// rotation. Without a key directory a throwaway key is generated in dev.
+func InitJWTKeys() {
+	if AppConfig.JWTKeyDir == "" {
+		if AppConfig.AppEnv != "dev" {
+			log.Fatal("JWT_KEY_DIR is required outside dev")
+		}
+		keys, err := newDevKeySet()
+		if err != nil {
+			log.Fatalf("Failed to generate dev signing key: %v", err)
+		}
+		log.Println("JWT_KEY_DIR not set; signing tokens with a temporary dev key")
+		JWTKeys = keys
+		return
+	}
+
+	keys, err := LoadKeySet(AppConfig.JWTKeyDir, AppConfig.JWTSigningKID)
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	JWTKeys = keys
+	log.Printf("Loaded %d JWT keys, signing with %q", len(keys.keys), keys.signing.ID)
+}
+
+// LoadKeySet reads the PEM keys in dir and selects signingKID for signing.
+// signingKID may be empty when the directory holds a single private key.
+func LoadKeySet(dir string, signingKID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	keys := &KeySet{keys: map[string]*SigningKey{}}
+	var privateKIDs []string
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := loadSigningKey(path, kid)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		keys.keys[kid] = key
+		if key.PrivateKey != nil {
+			privateKIDs = append(privateKIDs, kid)
+		}
+	}
+
+	if signingKID == "" {
+		if len(privateKIDs) != 1 {
+			return nil, fmt.Errorf("JWT_SIGNING_KID must be set when %s has %d private keys", dir, len(privateKIDs))
+		}
+		signingKID = privateKIDs[0]
+	}
+
+	signing, ok := keys.keys[signingKID]
+	if !ok || signing.PrivateKey == nil {
+		return nil, fmt.Errorf("no private key with kid %q in %s", signingKID, dir)
+	}
+	keys.signing = signing
+	return keys, nil
+}
+
+// loadSigningKey parses an RSA or Ed25519 key from a PEM file
+func loadSigningKey(path string, kid string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newSigningKey(kid, parsed)
+}
+
+// newSigningKey wraps a parsed key, pinning the algorithm it may be used with
+func newSigningKey(kid string, parsed interface{}) (*SigningKey, error) {
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, PrivateKey: key, PublicKey: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, PublicKey: key}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: kid, Method: SigningMethodEdDSA, PrivateKey: key, PublicKey: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &SigningKey{ID: kid, Method: SigningMethodEdDSA, PublicKey: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
+
+// newDevKeySet generates a single in-memory Ed25519 key
+func newDevKeySet() (*KeySet, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	key, err := newSigningKey("dev", privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySet{keys: map[string]*SigningKey{key.ID: key}, signing: key}, nil
+}
+
+// Sign signs the claims with the current signing key
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.ID
+	return token.SignedString(k.signing.PrivateKey)
+}
+
+// Keyfunc picks the verification key named by the token's kid header and
+// rejects tokens signed with any other algorithm than that key's
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.PublicKey, nil
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`   // RSA modulus
+	E         string `json:"e,omitempty"`   // RSA exponent
+	Curve     string `json:"crv,omitempty"` // OKP curve
+	X         string `json:"x,omitempty"`   // OKP public key
+}
+
+// JWKS returns the public verification keys as a JSON Web Key Set
+func (k *KeySet) JWKS() map[string][]JWK {
+	kids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	keys := make([]JWK, 0, len(kids))
+	for _, kid := range kids {
+		key := k.keys[kid]
+		jwk := JWK{KeyID: kid, Use: "sig", Algorithm: key.Method.Alg()}
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+		keys = append(keys, jwk)
+	}
+	return map[string][]JWK{"keys": keys}
+}
+
+// signingMethodEd25519 implements the EdDSA JWS algorithm for Ed25519 keys
+type signingMethodEd25519 struct{}
+
+func (m *signingMethodEd25519) Alg() string {
+	return "EdDSA"
+}
+
+func (m *signingMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+func (m *signingMethodEd25519) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Email is a message to send to a user
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails to users
+type Mailer interface {
+	Send(email Email) error
+}
+
+// AppMailer is the mailer used to send account emails
+var AppMailer Mailer = &LogMailer{}
+
+// InitMailer selects the mailer from the app configuration
+func InitMailer() {
+	switch AppConfig.Mailer {
+	case "", "log":
+		AppMailer = &LogMailer{Path: AppConfig.MailLogFile}
+	default:
+		log.Fatalf("Unknown MAILER: %s", AppConfig.Mailer)
+	}
+}
+
+// LogMailer is a local mailer that appends emails to a file, or writes them
+// to the application log when no file is set
+type LogMailer struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (m *LogMailer) Send(email Email) error {
+	entry := fmt.Sprintf("==== %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), email.To, email.Subject, email.Body)
+
+	if m.Path == "" {
+		log.Print(entry)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(entry)
+	return err
+}
@@ -0,0 +1,481 @@
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrUnknownOIDCProvider is returned for a provider name that isn't configured
+	ErrUnknownOIDCProvider = errors.New("unknown identity provider")
+	// ErrInvalidOIDCState is returned when a callback's state is unknown, expired or already used,
+	// or wasn't started by the browser presenting it
+	ErrInvalidOIDCState = errors.New("invalid or expired login state")
+	// ErrInvalidOIDCLoginCode is returned when a login code is unknown, expired or already used
+	ErrInvalidOIDCLoginCode = errors.New("invalid or expired login code")
+)
+
+const (
+	// OIDCStateTTL is how long a user has to complete the login at the provider
+	OIDCStateTTL = 10 * time.Minute
+	// OIDCStateCookie holds the state of the login started by the browser
+	OIDCStateCookie = "oidc_state"
+	// oidcLoginCodeTTL is how long the frontend has to exchange a login code
+	oidcLoginCodeTTL = time.Minute
+)
+
+// oidcHTTPClient talks to identity providers
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// OIDCProvider is an OpenID Connect identity provider users can log in with
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]interface{} // Provider signing keys by kid
+}
+
+// oidcDiscovery is the part of the provider metadata we use
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCIdentity is the verified identity a provider returned
+type OIDCIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// LoadOIDCProviders reads the providers named in OIDC_PROVIDERS (e.g.
+// "google,line"). Each provider is configured by OIDC_<NAME>_ISSUER,
+// OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET and optionally
+// OIDC_<NAME>_SCOPES.
+func LoadOIDCProviders(names string) (map[string]*OIDCProvider, error) {
+	providers := map[string]*OIDCProvider{}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := &OIDCProvider{
+			Name:         name,
+			Issuer:       strings.TrimRight(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       strings.Fields(getEnvString(prefix+"SCOPES", "openid email profile")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			return nil, fmt.Errorf("%sISSUER and %sCLIENT_ID are required", prefix, prefix)
+		}
+		providers[name] = provider
+	}
+	return providers, nil
+}
+
+// FindOIDCProvider returns the configured provider with the given name
+func FindOIDCProvider(name string) (*OIDCProvider, error) {
+	provider, ok := AppConfig.OIDCProviders[name]
+	if !ok {
+		return nil, ErrUnknownOIDCProvider
+	}
+	return provider, nil
+}
+
+// RedirectURI is the callback URL registered with the provider
+func (p *OIDCProvider) RedirectURI() string {
+	return strings.TrimRight(AppConfig.AppBaseURL, "/") + "/auth/oidc/" + p.Name + "/callback"
+}
+
+// BeginOIDCLogin records a new login attempt and returns the provider URL
+// to send the user to, and the state the browser must present on return
+func BeginOIDCLogin(provider *OIDCProvider) (string, string, error) {
+	discovery, err := provider.getDiscovery()
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	collection, ctx := GetCollection("oidc_states")
+	defer ctx.Done()
+
+	_, err = collection.InsertOne(ctx, models.OIDCState{
+		ID:           primitive.NewObjectID(),
+		StateHash:    HashToken(state),
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		CreatedAt:    primitive.NewDateTimeFromTime(now),
+		ExpiresAt:    primitive.NewDateTimeFromTime(now.Add(OIDCStateTTL)),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.ClientID},
+		"redirect_uri":          {provider.RedirectURI()},
+		"scope":                 {strings.Join(provider.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	return discovery.AuthorizationEndpoint + "?" + query.Encode(), state, nil
+}
+
+// CompleteOIDCLogin redeems the state and authorization code of a callback
+// and returns the identity from the verified ID token. The state must match
+// the one kept by the browser, so a callback URL minted for someone else's
+// account can't log the browser in.
+func CompleteOIDCLogin(provider *OIDCProvider, code string, state string, browserState string) (OIDCIdentity, error) {
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return OIDCIdentity{}, ErrInvalidOIDCState
+	}
+
+	collection, ctx := GetCollection("oidc_states")
+	defer ctx.Done()
+
+	// Each state can only be redeemed once
+	var loginState models.OIDCState
+	err := collection.FindOneAndDelete(ctx, bson.M{
+		"state_hash": HashToken(state),
+		"provider":   provider.Name,
+		"expires_at": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+	}).Decode(&loginState)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return OIDCIdentity{}, ErrInvalidOIDCState
+	}
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	idToken, err := provider.exchangeCode(code, loginState.CodeVerifier)
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+	return provider.verifyIDToken(idToken, loginState.Nonce)
+}
+
+// IssueOIDCLoginCode stores a single-use code the frontend exchanges for the
+// session of a user who signed in with a provider
+func IssueOIDCLoginCode(userID primitive.ObjectID, provider string) (string, error) {
+	code, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	collection, ctx := GetCollection("oidc_login_codes")
+	defer ctx.Done()
+
+	now := time.Now()
+	_, err = collection.InsertOne(ctx, models.OIDCLoginCode{
+		ID:        primitive.NewObjectID(),
+		CodeHash:  HashToken(code),
+		UserID:    userID,
+		Provider:  provider,
+		CreatedAt: primitive.NewDateTimeFromTime(now),
+		ExpiresAt: primitive.NewDateTimeFromTime(now.Add(oidcLoginCodeTTL)),
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// RedeemOIDCLoginCode consumes a login code and returns the user it was issued for
+func RedeemOIDCLoginCode(code string) (primitive.ObjectID, error) {
+	collection, ctx := GetCollection("oidc_login_codes")
+	defer ctx.Done()
+
+	var loginCode models.OIDCLoginCode
+	err := collection.FindOneAndDelete(ctx, bson.M{
+		"code_hash":  HashToken(code),
+		"expires_at": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+	}).Decode(&loginCode)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return primitive.NilObjectID, ErrInvalidOIDCLoginCode
+	}
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return loginCode.UserID, nil
+}
+
+// OIDCFrontendRedirect returns the frontend URL a provider sign in ends at,
+// carrying either a login code or an error
+func OIDCFrontendRedirect(values url.Values) string {
+	target, err := url.Parse(AppConfig.OIDCFrontendURL)
+	if err != nil {
+		return AppConfig.OIDCFrontendURL
+	}
+	query := target.Query()
+	for key, value := range values {
+		query[key] = value
+	}
+	target.RawQuery = query.Encode()
+	return target.String()
+}
+
+// exchangeCode trades an authorization code for the provider's ID token
+func (p *OIDCProvider) exchangeCode(code string, codeVerifier string) (string, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := oidcHTTPClient.PostForm(discovery.TokenEndpoint, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURI()},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {codeVerifier},
+	})
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("token request rejected: %s %s", body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+	return body.IDToken, nil
+}
+
+// verifyIDToken checks the ID token's signature, issuer, audience and nonce
+func (p *OIDCProvider) verifyIDToken(idToken string, nonce string) (OIDCIdentity, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	claims := jwt.MapClaims{}
+	parser := &jwt.Parser{ValidMethods: []string{"RS256", "ES256", "HS256"}}
+	if _, err := parser.ParseWithClaims(idToken, claims, p.keyfunc); err != nil {
+		return OIDCIdentity{}, fmt.Errorf("invalid id token: %v", err)
+	}
+
+	if claims["iss"] != discovery.Issuer {
+		return OIDCIdentity{}, errors.New("id token has the wrong issuer")
+	}
+	if !audienceContains(claims["aud"], p.ClientID) {
+		return OIDCIdentity{}, errors.New("id token has the wrong audience")
+	}
+	if claims["nonce"] != nonce {
+		return OIDCIdentity{}, errors.New("id token has the wrong nonce")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return OIDCIdentity{}, errors.New("id token has no expiry")
+	}
+
+	identity := OIDCIdentity{Provider: p.Name}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	// Some providers send email_verified as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+	if identity.Subject == "" {
+		return OIDCIdentity{}, errors.New("id token has no subject")
+	}
+	return identity, nil
+}
+
+// keyfunc returns the provider key an ID token was signed with. HS256 tokens
+// are signed with the client secret, as LINE does for web logins.
+func (p *OIDCProvider) keyfunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() == "HS256" {
+		if p.ClientSecret == "" {
+			return nil, errors.New("HS256 id tokens need a client secret")
+		}
+		return []byte(p.ClientSecret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, err := p.getKey(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if token.Method.Alg() != "RS256" {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+	case *ecdsa.PublicKey:
+		if token.Method.Alg() != "ES256" {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+	}
+	return key, nil
+}
+
+// getDiscovery fetches and caches the provider metadata
+func (p *OIDCProvider) getDiscovery() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := getJSON(p.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover %s: %v", p.Name, err)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("incomplete discovery document for %s", p.Name)
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// getKey returns the provider key with the given kid, refetching the key set
+// once when the kid is unknown in case the provider rotated its keys
+func (p *OIDCProvider) getKey(kid string) (interface{}, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	var jwks struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+			Curve   string `json:"crv"`
+			X       string `json:"x"`
+			Y       string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := getJSON(discovery.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s keys: %v", p.Name, err)
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range jwks.Keys {
+		switch {
+		case jwk.KeyType == "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+			e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			keys[jwk.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case jwk.KeyType == "EC" && jwk.Curve == "P-256":
+			x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+			y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[jwk.KeyID] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	p.keys = keys
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown %s signing key %q", p.Name, kid)
+	}
+	return key, nil
+}
+
+// audienceContains reports whether the aud claim, a string or a list, names the client
+func audienceContains(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, value := range aud {
+			if value == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getJSON fetches a URL and decodes its JSON body
+func getJSON(url string, out interface{}) error {
+	resp, err := oidcHTTPClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
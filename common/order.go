@@ -0,0 +1,89 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrOrderNotFound is returned when the order to transition does not exist
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrIllegalOrderTransition is returned when the requested status change is not allowed
+	ErrIllegalOrderTransition = errors.New("illegal order status transition")
+)
+
+// NewOrderStatusChange builds a status history entry stamped with the current time
+func NewOrderStatusChange(from, to string, actor Actor, note string) models.OrderStatusChange {
+	return models.OrderStatusChange{
+		From:      from,
+		To:        to,
+		ActorID:   actor.ID,
+		ActorRole: actor.Role,
+		Note:      note,
+		ChangedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+}
+
+// TransitionOrderStatus moves an order to a new status if the lifecycle allows
+// it and appends the change to the order's status history
+func TransitionOrderStatus(orderID primitive.ObjectID, to string, actor Actor, note string) (models.Order, error) {
+	collection, ctx := GetCollection("orders")
+	defer ctx.Done()
+
+	var order models.Order
+	if err := collection.FindOne(ctx, bson.M{"_id": orderID}).Decode(&order); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return order, ErrOrderNotFound
+		}
+		return order, err
+	}
+
+	if !models.CanTransitionOrder(order.Status, to) {
+		return order, fmt.Errorf("%w: %s to %s", ErrIllegalOrderTransition, order.Status, to)
+	}
+
+	change := NewOrderStatusChange(order.Status, to, actor, note)
+
+	// Only apply the change if nobody moved the order in the meantime
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": orderID, "status": order.Status}, bson.M{
+		"$set":  bson.M{"status": to, "updated_on": change.ChangedAt},
+		"$push": bson.M{"status_history": change},
+	})
+	if err != nil {
+		return order, err
+	}
+	if result.ModifiedCount == 0 {
+		return order, fmt.Errorf("%w: order status changed concurrently", ErrIllegalOrderTransition)
+	}
+
+	// Goods that never left the warehouse go back into stock
+	if releasesStock(change.From, to) {
+		if err := ReleaseStock(orderID, StockLinesFromOrderItems(order.Items)); err != nil {
+			log.Printf("Failed to release stock for order %s: %v", orderID.Hex(), err)
+		}
+	}
+
+	order.Status = to
+	order.UpdatedOn = change.ChangedAt
+	order.History = append(order.History, change)
+	return order, nil
+}
+
+// releasesStock reports whether a transition returns the order's reserved stock
+func releasesStock(from, to string) bool {
+	if to != models.OrderStatusCancelled && to != models.OrderStatusRefunded {
+		return false
+	}
+	switch from {
+	case models.OrderStatusPendingPayment, models.OrderStatusPaid, models.OrderStatusFulfilling:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package common
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// rolePermissionCache keeps the role_permissions collection in memory so
+// authorizing a request doesn't need a database round trip
+var rolePermissionCache = struct {
+	sync.RWMutex
+	roles    map[string]map[string]bool
+	loadedAt time.Time
+}{}
+
+// SeedRolePermissions stores the default permissions of roles that have
+// none yet. Stored sets, the admin's included, are left as the operator
+// edited them; permissions added in a release are granted through the
+// role permission endpoints.
+func SeedRolePermissions() {
+	collection, ctx := GetCollection("role_permissions")
+	defer ctx.Done()
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	for role, permissions := range models.DefaultRolePermissions {
+		update := bson.M{"$setOnInsert": bson.M{"permissions": permissions, "updated_at": now}}
+		if _, err := collection.UpdateOne(ctx, bson.M{"role": role}, update, options.Update().SetUpsert(true)); err != nil {
+			log.Fatalf("Failed to seed permissions for %s: %v", role, err)
+		}
+	}
+	InvalidatePermissionCache()
+}
+
+// ListRolePermissions returns the stored permissions of every role
+func ListRolePermissions() ([]models.RolePermissions, error) {
+	collection, ctx := GetCollection("role_permissions")
+	defer ctx.Done()
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"role": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	roles := []models.RolePermissions{}
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// SetRolePermissions replaces the permissions granted to a role
+func SetRolePermissions(role string, permissions []string) error {
+	collection, ctx := GetCollection("role_permissions")
+	defer ctx.Done()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"role": role}, bson.M{
+		"$set": bson.M{"permissions": permissions, "updated_at": primitive.NewDateTimeFromTime(time.Now())},
+	}, options.Update().SetUpsert(true))
+	InvalidatePermissionCache()
+	return err
+}
+
+// InvalidatePermissionCache makes the next lookup reload role permissions
+func InvalidatePermissionCache() {
+	rolePermissionCache.Lock()
+	defer rolePermissionCache.Unlock()
+	rolePermissionCache.roles = nil
+}
+
+// PermissionsForRole returns the set of permissions granted to a role
+func PermissionsForRole(role string) (map[string]bool, error) {
+	rolePermissionCache.RLock()
+	roles, loadedAt := rolePermissionCache.roles, rolePermissionCache.loadedAt
+	rolePermissionCache.RUnlock()
+
+	if roles == nil || time.Since(loadedAt) > AppConfig.PermissionCacheTTL {
+		stored, err := ListRolePermissions()
+		if err != nil {
+			return nil, err
+		}
+
+		roles = map[string]map[string]bool{}
+		for _, entry := range stored {
+			granted := map[string]bool{}
+			for _, permission := range entry.Permissions {
+				granted[permission] = true
+			}
+			roles[entry.Role] = granted
+		}
+
+		rolePermissionCache.Lock()
+		rolePermissionCache.roles, rolePermissionCache.loadedAt = roles, time.Now()
+		rolePermissionCache.Unlock()
+	}
+
+	return roles[role], nil
+}
+
+// RequirePermission authenticates the request and allows it when the
+// caller's role or API key has at least one of the permissions. Pass both
+// the global and the ":own" variant when the handler narrows the scope itself.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted, ok := authenticate(c)
+		if !ok {
+			return
+		}
+
+		allowed := false
+		for _, permission := range permissions {
+			if granted[permission] {
+				allowed = true
+				break
+			}
+		}
+
+		impersonating := c.GetString("impersonatorID") != ""
+		if !allowed {
+			message := "You do not have permission to perform this action"
+			if impersonating {
+				message = "This action is not allowed while impersonating a user"
+			}
+			c.JSON(http.StatusForbidden, gin.H{"message": message, "status_code": http.StatusForbidden})
+			c.Abort()
+			return
+		}
+
+		c.Set("permissions", granted)
+		c.Next()
+
+		// Everything done with an impersonation token goes to the audit trail
+		if impersonating {
+			RecordImpersonatedRequest(c)
+		}
+	}
+}
+
+// withoutPermissions returns a copy of granted without the given permissions
+func withoutPermissions(granted map[string]bool, removed []string) map[string]bool {
+	remaining := make(map[string]bool, len(granted))
+	for permission, ok := range granted {
+		remaining[permission] = ok
+	}
+	for _, permission := range removed {
+		delete(remaining, permission)
+	}
+	return remaining
+}
+
+// HasPermission reports whether the authenticated caller holds a permission
+func HasPermission(c *gin.Context, permission string) bool {
+	granted, _ := c.Get("permissions")
+	permissions, _ := granted.(map[string]bool)
+	return permissions[permission]
+}
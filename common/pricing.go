@@ -0,0 +1,202 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PriceBreakdown is the server-side computed pricing of a cart
+type PriceBreakdown struct {
+	Items       []models.CartItem `json:"items"`
+	SubTotal    models.Money      `json:"sub_total"`
+	DeliveryFee models.Money      `json:"delivery_fee"`
+	Tax         models.Money      `json:"tax"`          // Tax on the items and the delivery fee
+	DeliveryTax models.Money      `json:"delivery_tax"` // Part of Tax charged on the delivery fee
+	TaxLines    []models.TaxLine  `json:"tax_lines"`    // Tax per tax class
+	Total       models.Money      `json:"total"`
+
+	// PricesIncludeTax tells whether SubTotal and DeliveryFee already include Tax
+	PricesIncludeTax bool           `json:"prices_include_tax"`
+	Delivery         *DeliveryQuote `json:"delivery,omitempty"` // Nil when delivery can't be quoted yet
+
+	// DeliveryUnavailable explains why Delivery is nil
+	DeliveryUnavailable string `json:"delivery_unavailable,omitempty"`
+
+	// Products holds the priced products keyed by ID for snapshotting
+	Products map[primitive.ObjectID]models.Product `json:"-"`
+}
+
+// PriceCartItems looks up the current product prices and computes line totals,
+// sub total, delivery fee, tax and grand total for the given items shipped to
+// addr. Tax is rounded per line; the grand total adds it on top of the
+// catalog prices only when they exclude tax.
+func PriceCartItems(items []models.CartItem, addr models.ShippingAddress) (PriceBreakdown, error) {
+	zero := models.NewMoney(0, AppConfig.StoreCurrency)
+	breakdown := PriceBreakdown{
+		Items:            []models.CartItem{},
+		SubTotal:         zero,
+		DeliveryFee:      zero,
+		Tax:              zero,
+		DeliveryTax:      zero,
+		TaxLines:         []models.TaxLine{},
+		PricesIncludeTax: AppConfig.PricesIncludeTax,
+	}
+
+	products, err := findProductsForItems(items)
+	if err != nil {
+		return breakdown, err
+	}
+	breakdown.Products = products
+
+	taxes, err := LoadTaxCalculator(products)
+	if err != nil {
+		return breakdown, err
+	}
+	taxLines := []models.TaxLine{}
+
+	for _, item := range items {
+		if item.Qty <= 0 {
+			return breakdown, fmt.Errorf("invalid quantity for product %s", item.ProductID.Hex())
+		}
+		product, ok := products[item.ProductID]
+		if !ok {
+			return breakdown, fmt.Errorf("product not found: %s", item.ProductID.Hex())
+		}
+
+		price, err := UnitPrice(product, item.VariantID)
+		if err != nil {
+			return breakdown, fmt.Errorf("%w: %s", err, item.ProductID.Hex())
+		}
+
+		class, err := taxes.ClassFor(product)
+		if err != nil {
+			return breakdown, err
+		}
+
+		item.Total = price.Mul(item.Qty)
+		line, err := taxes.Line(item.Total, class)
+		if err != nil {
+			return breakdown, err
+		}
+		item.TaxClass, item.TaxRate = class.Code, class.RateBasisPoints
+		item.Net, item.Tax = line.Net, line.Tax
+		taxLines = append(taxLines, line)
+		if breakdown.Tax, err = breakdown.Tax.Add(item.Tax); err != nil {
+			return breakdown, err
+		}
+		if breakdown.SubTotal, err = breakdown.SubTotal.Add(item.Total); err != nil {
+			return breakdown, err
+		}
+		breakdown.Items = append(breakdown.Items, item)
+	}
+
+	if len(breakdown.Items) > 0 {
+		quote, err := QuoteDelivery(breakdown.SubTotal, addr)
+		switch {
+		case err == nil:
+			breakdown.Delivery = &quote
+			breakdown.DeliveryFee = quote.Fee
+		case errors.Is(err, ErrMissingCoordinates), errors.Is(err, ErrOutsideDeliveryArea):
+			breakdown.DeliveryUnavailable = err.Error()
+		default:
+			return breakdown, err
+		}
+	}
+
+	deliveryLines, err := taxes.DeliveryTax(breakdown.DeliveryFee, breakdown.Items)
+	if err != nil {
+		return breakdown, err
+	}
+	for _, line := range deliveryLines {
+		if breakdown.DeliveryTax, err = breakdown.DeliveryTax.Add(line.Tax); err != nil {
+			return breakdown, err
+		}
+	}
+	if breakdown.Tax, err = breakdown.Tax.Add(breakdown.DeliveryTax); err != nil {
+		return breakdown, err
+	}
+	if breakdown.TaxLines, err = SummarizeTax(append(taxLines, deliveryLines...)); err != nil {
+		return breakdown, err
+	}
+
+	if breakdown.Total, err = breakdown.SubTotal.Add(breakdown.DeliveryFee); err != nil {
+		return breakdown, err
+	}
+	if !breakdown.PricesIncludeTax {
+		if breakdown.Total, err = breakdown.Total.Add(breakdown.Tax); err != nil {
+			return breakdown, err
+		}
+	}
+
+	return breakdown, nil
+}
+
+// CheckStoreAmount checks that an amount sent by a client is not negative
+// and is in the store currency, filling in the currency when it was omitted
+func CheckStoreAmount(amount *models.Money) error {
+	if amount.Currency == "" {
+		amount.Currency = AppConfig.StoreCurrency
+	}
+	if amount.Currency != AppConfig.StoreCurrency {
+		return fmt.Errorf("amounts must be in %s", AppConfig.StoreCurrency)
+	}
+	if amount.IsNegative() {
+		return fmt.Errorf("amounts must not be negative")
+	}
+	return nil
+}
+
+// UnitPrice returns the price of a product, or of the chosen variant for
+// products sold through variants
+func UnitPrice(product models.Product, variantID primitive.ObjectID) (models.Money, error) {
+	if !product.HasVariants() {
+		if !variantID.IsZero() {
+			return models.Money{}, ErrVariantNotFound
+		}
+		return product.Price, nil
+	}
+	if variantID.IsZero() {
+		return models.Money{}, ErrVariantRequired
+	}
+	variant, ok := product.FindVariant(variantID)
+	if !ok {
+		return models.Money{}, ErrVariantNotFound
+	}
+	return variant.Price, nil
+}
+
+// findProductsForItems loads the products referenced by the items keyed by ID
+func findProductsForItems(items []models.CartItem) (map[primitive.ObjectID]models.Product, error) {
+	products := map[primitive.ObjectID]models.Product{}
+	if len(items) == 0 {
+		return products, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+
+	collection, ctx := GetCollection("products")
+	defer ctx.Done()
+
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch products: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var found []models.Product
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, fmt.Errorf("failed to decode products: %v", err)
+	}
+
+	for _, product := range found {
+		products[product.ID] = product
+	}
+	return products, nil
+}
@@ -0,0 +1,246 @@
+package common
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired or revoked
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReuse is returned when an already rotated refresh token is presented
+	ErrRefreshTokenReuse = errors.New("refresh token reuse detected")
+)
+
+// lastSeenInterval limits how often a session's last-seen time is written
+const lastSeenInterval = time.Minute
+
+// TokenPair is the set of tokens issued for a session
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // Access token lifetime in seconds
+	SessionID    string `json:"session_id"`
+}
+
+// HashToken returns the SHA-256 hex digest of an opaque token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateOpaqueToken returns a random URL-safe token
+func GenerateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// CreateSession starts a session for the user on the requesting device and
+// issues its first access and refresh tokens
+func CreateSession(user models.User, c *gin.Context) (TokenPair, error) {
+	refreshToken, err := GenerateOpaqueToken()
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		ID:                 primitive.NewObjectID(),
+		UserID:             user.ID,
+		RefreshTokenHash:   HashToken(refreshToken),
+		RotatedTokenHashes: []string{},
+		UserAgent:          c.Request.UserAgent(),
+		IP:                 c.ClientIP(),
+		CreatedAt:          primitive.NewDateTimeFromTime(now),
+		LastSeenAt:         primitive.NewDateTimeFromTime(now),
+		ExpiresAt:          primitive.NewDateTimeFromTime(now.Add(AppConfig.RefreshTokenTTL)),
+	}
+
+	collection, ctx := GetCollection("sessions")
+	defer ctx.Done()
+
+	if _, err := collection.InsertOne(ctx, session); err != nil {
+		return TokenPair{}, err
+	}
+
+	return issueTokenPair(user, session.ID, refreshToken)
+}
+
+// RotateSession exchanges a refresh token for a new token pair. Presenting a
+// refresh token that was already rotated revokes the whole session, since
+// either the client or an attacker holds a stolen copy.
+func RotateSession(refreshToken string, c *gin.Context) (TokenPair, error) {
+	collection, ctx := GetCollection("sessions")
+	defer ctx.Done()
+
+	hash := HashToken(refreshToken)
+
+	var session models.Session
+	err := collection.FindOne(ctx, bson.M{"refresh_token_hash": hash}).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if err := collection.FindOne(ctx, bson.M{"rotated_token_hashes": hash}).Decode(&session); err == nil {
+			RevokeSession(session.ID, models.SessionRevokedReuse)
+			return TokenPair{}, ErrRefreshTokenReuse
+		}
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	now := time.Now()
+	if session.RevokedAt != 0 || session.ExpiresAt.Time().Before(now) {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	var user models.User
+	users, usersCtx := GetCollection("users")
+	defer usersCtx.Done()
+	if err := users.FindOne(usersCtx, bson.M{"_id": session.UserID}).Decode(&user); err != nil {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, err := GenerateOpaqueToken()
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	// Only rotate if the token hasn't been used concurrently
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": session.ID, "refresh_token_hash": hash}, bson.M{
+		"$set": bson.M{
+			"refresh_token_hash": HashToken(newRefreshToken),
+			"last_seen_at":       primitive.NewDateTimeFromTime(now),
+			"user_agent":         c.Request.UserAgent(),
+			"ip":                 c.ClientIP(),
+		},
+		"$push": bson.M{"rotated_token_hashes": hash},
+	})
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if result.ModifiedCount == 0 {
+		RevokeSession(session.ID, models.SessionRevokedReuse)
+		return TokenPair{}, ErrRefreshTokenReuse
+	}
+
+	return issueTokenPair(user, session.ID, newRefreshToken)
+}
+
+// RevokeSession ends a session so its tokens stop working
+func RevokeSession(sessionID primitive.ObjectID, reason string) error {
+	collection, ctx := GetCollection("sessions")
+	defer ctx.Done()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": sessionID, "revoked_at": bson.M{"$exists": false}}, bson.M{
+		"$set": bson.M{"revoked_at": primitive.NewDateTimeFromTime(time.Now()), "revoked_reason": reason},
+	})
+	return err
+}
+
+// RevokeUserSessions ends every session of a user except the given one
+func RevokeUserSessions(userID primitive.ObjectID, exceptSessionID primitive.ObjectID, reason string) error {
+	collection, ctx := GetCollection("sessions")
+	defer ctx.Done()
+
+	_, err := collection.UpdateMany(ctx, bson.M{
+		"user_id":    userID,
+		"_id":        bson.M{"$ne": exceptSessionID},
+		"revoked_at": bson.M{"$exists": false},
+	}, bson.M{
+		"$set": bson.M{"revoked_at": primitive.NewDateTimeFromTime(time.Now()), "revoked_reason": reason},
+	})
+	return err
+}
+
+// ListActiveSessions returns the user's sessions that can still be used
+func ListActiveSessions(userID primitive.ObjectID) ([]models.Session, error) {
+	collection, ctx := GetCollection("sessions")
+	defer ctx.Done()
+
+	cursor, err := collection.Find(ctx, bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	if sessions == nil {
+		sessions = []models.Session{}
+	}
+	return sessions, nil
+}
+
+// isSessionActive checks that the session behind an access token is still
+// usable and that its user still exists with the role the token was issued
+// for, and records the device as recently seen
+func isSessionActive(sessionID string, userID string, role string) bool {
+	sessionObjectID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return false
+	}
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false
+	}
+
+	collection, ctx := GetCollection("sessions")
+	defer ctx.Done()
+
+	now := time.Now()
+	filter := bson.M{
+		"_id":        sessionObjectID,
+		"user_id":    userObjectID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": primitive.NewDateTimeFromTime(now)},
+	}
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil || count == 0 {
+		return false
+	}
+
+	// A deleted or demoted user must not keep acting with the old role
+	users, usersCtx := GetCollection("users")
+	defer usersCtx.Done()
+	count, err = users.CountDocuments(usersCtx, bson.M{"_id": userObjectID, "role": role})
+	if err != nil || count == 0 {
+		return false
+	}
+
+	filter["last_seen_at"] = bson.M{"$lt": primitive.NewDateTimeFromTime(now.Add(-lastSeenInterval))}
+	collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"last_seen_at": primitive.NewDateTimeFromTime(now)}})
+	return true
+}
+
+// issueTokenPair signs an access token for the session alongside its refresh token
+func issueTokenPair(user models.User, sessionID primitive.ObjectID, refreshToken string) (TokenPair, error) {
+	accessToken, err := GenerateToken(user.ID.Hex(), user.Role, sessionID.Hex())
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(AppConfig.AccessTokenTTL.Seconds()),
+		SessionID:    sessionID.Hex(),
+	}, nil
+}
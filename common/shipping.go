@@ -0,0 +1,263 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// earthRadiusKm is the mean radius of the earth used for haversine distances
+const earthRadiusKm = 6371.0
+
+var (
+	// ErrMissingCoordinates is returned when a shipping address has no GPS position
+	ErrMissingCoordinates = errors.New("shipping address has no coordinates")
+	// ErrOutsideDeliveryArea is returned when no delivery zone covers the shipping address
+	ErrOutsideDeliveryArea = errors.New("shipping address is outside our delivery area")
+)
+
+// DeliveryFeeCalculator computes delivery fees from the distance between the
+// store and the shipping address
+type DeliveryFeeCalculator struct {
+	OriginLatitude        float64
+	OriginLongitude       float64
+	BaseFee               models.Money
+	Tiers                 []models.FeeTier
+	FreeShippingThreshold models.Money // Sub total from which delivery is free, zero disables it
+}
+
+// DeliveryQuote is the result of a delivery fee calculation
+type DeliveryQuote struct {
+	DistanceKm   float64            `json:"distance_km"`
+	Fee          models.Money       `json:"fee"`
+	FreeShipping bool               `json:"free_shipping"`
+	ZoneID       primitive.ObjectID `json:"zone_id,omitempty"`
+	ZoneName     string             `json:"zone_name,omitempty"`
+	LeadTimeDays int                `json:"lead_time_days"`
+}
+
+// DefaultDeliveryFeeCalculator builds the calculator from the app configuration
+func DefaultDeliveryFeeCalculator() DeliveryFeeCalculator {
+	return DeliveryFeeCalculator{
+		OriginLatitude:        AppConfig.StoreLatitude,
+		OriginLongitude:       AppConfig.StoreLongitude,
+		BaseFee:               AppConfig.DeliveryBaseFee,
+		Tiers:                 AppConfig.DeliveryFeeTiers,
+		FreeShippingThreshold: AppConfig.FreeShippingThreshold,
+	}
+}
+
+// ZoneDeliveryFeeCalculator builds the calculator from a delivery zone's fee table
+func ZoneDeliveryFeeCalculator(zone models.DeliveryZone) DeliveryFeeCalculator {
+	return DeliveryFeeCalculator{
+		OriginLatitude:        AppConfig.StoreLatitude,
+		OriginLongitude:       AppConfig.StoreLongitude,
+		BaseFee:               zone.BaseFee,
+		Tiers:                 zone.FeeTiers,
+		FreeShippingThreshold: zone.FreeShippingThreshold,
+	}
+}
+
+// QuoteDelivery prices delivery of a sub total to the address using the
+// delivery zone that contains it. While no zones are configured, the
+// default calculator applies everywhere.
+func QuoteDelivery(subTotal models.Money, addr models.ShippingAddress) (DeliveryQuote, error) {
+	if !HasCoordinates(addr) {
+		return DeliveryQuote{}, ErrMissingCoordinates
+	}
+
+	zone, err := FindDeliveryZone(addr)
+	if errors.Is(err, ErrOutsideDeliveryArea) {
+		configured, countErr := hasActiveDeliveryZones()
+		if countErr != nil {
+			return DeliveryQuote{}, countErr
+		}
+		if !configured {
+			return DefaultDeliveryFeeCalculator().Quote(subTotal, addr)
+		}
+	}
+	if err != nil {
+		return DeliveryQuote{}, err
+	}
+
+	quote, err := ZoneDeliveryFeeCalculator(zone).Quote(subTotal, addr)
+	if err != nil {
+		return quote, err
+	}
+	quote.ZoneID = zone.ID
+	quote.ZoneName = zone.Name
+	quote.LeadTimeDays = zone.LeadTimeDays
+	return quote, nil
+}
+
+// FindDeliveryZone finds the active delivery zone containing the address
+func FindDeliveryZone(addr models.ShippingAddress) (models.DeliveryZone, error) {
+	collection, ctx := GetCollection("delivery_zones")
+	defer ctx.Done()
+
+	var zone models.DeliveryZone
+	err := collection.FindOne(ctx, bson.M{
+		"active": true,
+		"area": bson.M{"$geoIntersects": bson.M{
+			"$geometry": models.NewGeoPoint(addr.Latitude, addr.Longitude),
+		}},
+	}).Decode(&zone)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return zone, ErrOutsideDeliveryArea
+	}
+	return zone, err
+}
+
+// hasActiveDeliveryZones reports whether any delivery zone is active
+func hasActiveDeliveryZones() (bool, error) {
+	collection, ctx := GetCollection("delivery_zones")
+	defer ctx.Done()
+
+	count, err := collection.CountDocuments(ctx, bson.M{"active": true})
+	return count > 0, err
+}
+
+// HasCoordinates reports whether a shipping address has a GPS position
+func HasCoordinates(addr models.ShippingAddress) bool {
+	return addr.Latitude != 0 || addr.Longitude != 0
+}
+
+// Quote computes the delivery fee for a sub total shipped to the address
+func (calc DeliveryFeeCalculator) Quote(subTotal models.Money, addr models.ShippingAddress) (DeliveryQuote, error) {
+	if !HasCoordinates(addr) {
+		return DeliveryQuote{}, ErrMissingCoordinates
+	}
+
+	quote := DeliveryQuote{
+		DistanceKm: roundAmount(HaversineKm(calc.OriginLatitude, calc.OriginLongitude, addr.Latitude, addr.Longitude)),
+		Fee:        models.NewMoney(0, subTotal.Currency),
+	}
+
+	if !calc.FreeShippingThreshold.IsZero() {
+		cmp, err := subTotal.Cmp(calc.FreeShippingThreshold)
+		if err != nil {
+			return DeliveryQuote{}, err
+		}
+		if cmp >= 0 {
+			quote.FreeShipping = true
+			return quote, nil
+		}
+	}
+
+	fee, err := quote.Fee.Add(calc.BaseFee)
+	if err != nil {
+		return DeliveryQuote{}, err
+	}
+	if quote.Fee, err = fee.Add(tieredDistanceFee(quote.DistanceKm, calc.Tiers, subTotal.Currency)); err != nil {
+		return DeliveryQuote{}, err
+	}
+	return quote, nil
+}
+
+// tieredDistanceFee charges each kilometre at the rate of the tier it falls
+// in, rounding the sum once to the minor unit. Tiers are validated when they
+// are configured; out of order tiers stored earlier never make it negative.
+func tieredDistanceFee(distanceKm float64, tiers []models.FeeTier, currency string) models.Money {
+	fee := new(big.Rat)
+	from := 0.0
+	for _, tier := range tiers {
+		if distanceKm <= from {
+			break
+		}
+		to := distanceKm
+		if tier.UpToKm > 0 && tier.UpToKm < distanceKm {
+			to = tier.UpToKm
+		}
+		if to > from {
+			fee.Add(fee, new(big.Rat).Mul(exactKm(to-from), new(big.Rat).SetInt64(tier.PerKm.Amount)))
+		}
+		if tier.UpToKm <= 0 {
+			break
+		}
+		from = math.Max(from, tier.UpToKm)
+	}
+	if fee.Sign() < 0 {
+		fee.SetInt64(0)
+	}
+	return models.MoneyFromRat(fee, currency)
+}
+
+// exactKm turns a distance into an exact fraction, to the metre
+func exactKm(distanceKm float64) *big.Rat {
+	return big.NewRat(int64(math.Round(distanceKm*1000)), 1000)
+}
+
+// HaversineKm returns the great-circle distance between two points in kilometres
+func HaversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRadians := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// ParseFeeTiers parses tiers written as "upToKm:perKm" pairs separated by
+// commas, e.g. "5:10,20:8,0:6", where the last tier is open-ended
+func ParseFeeTiers(value string, currency string) ([]models.FeeTier, error) {
+	var tiers []models.FeeTier
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid fee tier %q", part)
+		}
+		upTo, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fee tier distance %q", fields[0])
+		}
+		perKm, err := models.ParseMoney(fields[1], currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fee tier rate %q", fields[1])
+		}
+		if perKm.IsNegative() {
+			return nil, fmt.Errorf("fee tier rate %q must not be negative", fields[1])
+		}
+		tiers = append(tiers, models.FeeTier{UpToKm: upTo, PerKm: perKm})
+	}
+	if err := ValidateFeeTiers(tiers); err != nil {
+		return nil, err
+	}
+	return tiers, nil
+}
+
+// ValidateFeeTiers checks that tier limits are strictly ascending and that
+// only the last tier is open-ended (0 km), so every kilometre is charged once
+func ValidateFeeTiers(tiers []models.FeeTier) error {
+	for i, tier := range tiers {
+		last := i == len(tiers)-1
+		switch {
+		case tier.UpToKm < 0:
+			return fmt.Errorf("fee tier distances must not be negative")
+		case tier.UpToKm == 0 && !last:
+			return fmt.Errorf("only the last fee tier may be open-ended")
+		case i > 0 && tier.UpToKm != 0 && tier.UpToKm <= tiers[i-1].UpToKm:
+			return fmt.Errorf("fee tier distances must be strictly ascending")
+		case tier.UpToKm != 0 && last:
+			return fmt.Errorf("the last fee tier must be open-ended (0 km)")
+		}
+	}
+	return nil
+}
+
+// roundAmount rounds to two decimal places
+func roundAmount(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,272 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeliveryTaxProportional as DELIVERY_TAX_CLASS splits the delivery fee
+// across the tax classes of the items in proportion to their value, the way
+// an incidental charge follows the supply it belongs to
+const DeliveryTaxProportional = "proportional"
+
+// ErrUnknownTaxClass is returned when a configured or referenced tax class doesn't exist
+var ErrUnknownTaxClass = errors.New("unknown tax class")
+
+// basisPoints is the denominator of tax rates, 10000 being 100%
+const basisPoints = 10000
+
+// SeedTaxClasses creates the default tax classes that don't exist yet,
+// leaving classes edited by an admin alone, then checks that the configured
+// default and delivery classes exist so pricing can't fail on every cart
+func SeedTaxClasses() {
+	collection, ctx := GetCollection("tax_classes")
+	defer ctx.Done()
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	for _, class := range models.DefaultTaxClasses {
+		update := bson.M{"$setOnInsert": bson.M{
+			"name":              class.Name,
+			"rate_basis_points": class.RateBasisPoints,
+			"exempt":            class.Exempt,
+			"created_at":        now,
+			"updated_on":        now,
+		}}
+		if _, err := collection.UpdateOne(ctx, bson.M{"code": class.Code}, update, options.Update().SetUpsert(true)); err != nil {
+			log.Fatalf("Failed to seed tax class %s: %v", class.Code, err)
+		}
+	}
+
+	configured := map[string]string{"DEFAULT_TAX_CLASS": AppConfig.DefaultTaxClass}
+	if AppConfig.DeliveryTaxClass != DeliveryTaxProportional {
+		configured["DELIVERY_TAX_CLASS"] = AppConfig.DeliveryTaxClass
+	}
+	for key, code := range configured {
+		count, err := collection.CountDocuments(ctx, bson.M{"code": code})
+		if err != nil {
+			log.Fatalf("Failed to check tax class %s: %v", code, err)
+		}
+		if count == 0 {
+			log.Fatalf("%s: %v %q", key, ErrUnknownTaxClass, code)
+		}
+	}
+}
+
+// TaxCalculator splits amounts at catalog prices into net and tax using the
+// tax class of each product's category
+type TaxCalculator struct {
+	PricesIncludeTax bool
+	classes          map[string]models.TaxClass                // By code
+	classIDs         map[primitive.ObjectID]string             // Code by class ID
+	categoryClasses  map[primitive.ObjectID]primitive.ObjectID // Class ID by category ID
+}
+
+// LoadTaxCalculator loads the tax classes and the classes of the categories
+// of the given products
+func LoadTaxCalculator(products map[primitive.ObjectID]models.Product) (TaxCalculator, error) {
+	calc := TaxCalculator{
+		PricesIncludeTax: AppConfig.PricesIncludeTax,
+		classes:          map[string]models.TaxClass{},
+		classIDs:         map[primitive.ObjectID]string{},
+		categoryClasses:  map[primitive.ObjectID]primitive.ObjectID{},
+	}
+
+	classCollection, ctx := GetCollection("tax_classes")
+	defer ctx.Done()
+
+	cursor, err := classCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return calc, fmt.Errorf("failed to fetch tax classes: %v", err)
+	}
+	var classes []models.TaxClass
+	if err := cursor.All(ctx, &classes); err != nil {
+		return calc, fmt.Errorf("failed to decode tax classes: %v", err)
+	}
+	for _, class := range classes {
+		calc.classes[class.Code] = class
+		calc.classIDs[class.ID] = class.Code
+	}
+
+	categoryIDs := []primitive.ObjectID{}
+	for _, product := range products {
+		if !product.IDCategory.IsZero() {
+			categoryIDs = append(categoryIDs, product.IDCategory)
+		}
+	}
+	if len(categoryIDs) == 0 {
+		return calc, nil
+	}
+
+	categoryCollection, ctx := GetCollection("product_category")
+	defer ctx.Done()
+
+	cursor, err = categoryCollection.Find(ctx, bson.M{"_id": bson.M{"$in": categoryIDs}})
+	if err != nil {
+		return calc, fmt.Errorf("failed to fetch product categories: %v", err)
+	}
+	var categories []models.ProductCategory
+	if err := cursor.All(ctx, &categories); err != nil {
+		return calc, fmt.Errorf("failed to decode product categories: %v", err)
+	}
+	for _, category := range categories {
+		if !category.TaxClassID.IsZero() {
+			calc.categoryClasses[category.ID] = category.TaxClassID
+		}
+	}
+	return calc, nil
+}
+
+// ClassFor returns the tax class of a product: its category's class, or the
+// default class when the category has none
+func (calc TaxCalculator) ClassFor(product models.Product) (models.TaxClass, error) {
+	code := AppConfig.DefaultTaxClass
+	if classID, ok := calc.categoryClasses[product.IDCategory]; ok {
+		if code, ok = calc.classIDs[classID]; !ok {
+			return models.TaxClass{}, fmt.Errorf("%w %s of category %s", ErrUnknownTaxClass, classID.Hex(), product.IDCategory.Hex())
+		}
+	}
+	return calc.class(code)
+}
+
+// Split divides an amount at catalog prices into the amount excluding tax
+// and the tax on it, rounded to the minor unit
+func (calc TaxCalculator) Split(amount models.Money, class models.TaxClass) (net models.Money, tax models.Money, err error) {
+	tax = models.NewMoney(0, amount.Currency)
+	if class.Exempt || class.RateBasisPoints == 0 {
+		return amount, tax, nil
+	}
+
+	rate := int64(class.RateBasisPoints)
+	if calc.PricesIncludeTax {
+		// The price holds rate/10000 of the net on top of the net itself
+		tax = amount.MulRat(big.NewRat(rate, basisPoints+rate))
+		net, err = amount.Sub(tax)
+		return net, tax, err
+	}
+	return amount, amount.MulRat(big.NewRat(rate, basisPoints)), nil
+}
+
+// Line returns the tax breakdown of an amount in one tax class
+func (calc TaxCalculator) Line(amount models.Money, class models.TaxClass) (models.TaxLine, error) {
+	net, tax, err := calc.Split(amount, class)
+	if err != nil {
+		return models.TaxLine{}, err
+	}
+	return models.TaxLine{
+		TaxClass:        class.Code,
+		Name:            class.Name,
+		RateBasisPoints: class.RateBasisPoints,
+		Exempt:          class.Exempt,
+		Net:             net,
+		Tax:             tax,
+	}, nil
+}
+
+// DeliveryTax returns the tax breakdown of the delivery fee of priced items,
+// in the configured delivery class or split across the classes of the items
+func (calc TaxCalculator) DeliveryTax(fee models.Money, items []models.CartItem) ([]models.TaxLine, error) {
+	if fee.IsZero() {
+		return nil, nil
+	}
+	if AppConfig.DeliveryTaxClass != DeliveryTaxProportional {
+		class, err := calc.class(AppConfig.DeliveryTaxClass)
+		if err != nil {
+			return nil, err
+		}
+		return calc.lines(fee, class)
+	}
+
+	// Value of the items per class, in the order the classes first appear
+	codes := []string{}
+	values := map[string]int64{}
+	var itemsTotal int64
+	for _, item := range items {
+		if _, ok := values[item.TaxClass]; !ok {
+			codes = append(codes, item.TaxClass)
+		}
+		values[item.TaxClass] += item.Total.Amount
+		itemsTotal += item.Total.Amount
+	}
+	if itemsTotal <= 0 {
+		class, err := calc.class(AppConfig.DefaultTaxClass)
+		if err != nil {
+			return nil, err
+		}
+		return calc.lines(fee, class)
+	}
+
+	// Each class takes its rounded share and the last one the remainder, so
+	// the shares add up to the fee
+	lines := make([]models.TaxLine, 0, len(codes))
+	remaining := fee
+	for i, code := range codes {
+		class, err := calc.class(code)
+		if err != nil {
+			return nil, err
+		}
+		share := remaining
+		if i < len(codes)-1 {
+			share = fee.MulRat(big.NewRat(values[code], itemsTotal))
+		}
+		if remaining, err = remaining.Sub(share); err != nil {
+			return nil, err
+		}
+		line, err := calc.Line(share, class)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// lines returns the tax breakdown of an amount in one tax class as a list
+func (calc TaxCalculator) lines(amount models.Money, class models.TaxClass) ([]models.TaxLine, error) {
+	line, err := calc.Line(amount, class)
+	if err != nil {
+		return nil, err
+	}
+	return []models.TaxLine{line}, nil
+}
+
+// class returns a tax class by code
+func (calc TaxCalculator) class(code string) (models.TaxClass, error) {
+	class, ok := calc.classes[code]
+	if !ok {
+		return models.TaxClass{}, fmt.Errorf("%w %q", ErrUnknownTaxClass, code)
+	}
+	return class, nil
+}
+
+// SummarizeTax adds up tax lines of the same class, keeping the order in
+// which the classes first appear
+func SummarizeTax(lines []models.TaxLine) ([]models.TaxLine, error) {
+	summary := []models.TaxLine{}
+	index := map[string]int{}
+	for _, line := range lines {
+		i, ok := index[line.TaxClass]
+		if !ok {
+			index[line.TaxClass] = len(summary)
+			summary = append(summary, line)
+			continue
+		}
+		net, err := summary[i].Net.Add(line.Net)
+		if err != nil {
+			return nil, err
+		}
+		tax, err := summary[i].Tax.Add(line.Tax)
+		if err != nil {
+			return nil, err
+		}
+		summary[i].Net, summary[i].Tax = net, tax
+	}
+	return summary, nil
+}
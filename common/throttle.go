@@ -0,0 +1,170 @@
+package common
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrLoginLocked is returned while an account or IP address is locked out
+var ErrLoginLocked = errors.New("too many failed login attempts")
+
+// accountAttemptKey is the login_attempts key for an account
+func accountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// ipAttemptKey is the login_attempts key for an IP address
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// CheckLoginAllowed returns ErrLoginLocked and the time left when either the
+// account or the IP address is currently locked out
+func CheckLoginAllowed(email string, ip string) (time.Duration, error) {
+	collection, ctx := GetCollection("login_attempts")
+	defer ctx.Done()
+
+	now := time.Now()
+	cursor, err := collection.Find(ctx, bson.M{
+		"key":          bson.M{"$in": []string{accountAttemptKey(email), ipAttemptKey(ip)}},
+		"locked_until": bson.M{"$gt": primitive.NewDateTimeFromTime(now)},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var attempts []models.LoginAttempt
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return 0, err
+	}
+
+	var retryAfter time.Duration
+	for _, attempt := range attempts {
+		if wait := attempt.LockedUntil.Time().Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return retryAfter, ErrLoginLocked
+	}
+	return 0, nil
+}
+
+// RecordLoginFailure counts a failed login against the account and the IP
+// address, locking either out once it passes its threshold
+func RecordLoginFailure(email string, ip string) (accountLocked bool, ipLocked bool) {
+	accountLocked = recordAttemptFailure(accountAttemptKey(email), AppConfig.LoginMaxAttempts)
+	ipLocked = recordAttemptFailure(ipAttemptKey(ip), AppConfig.LoginIPMaxAttempts)
+	return accountLocked, ipLocked
+}
+
+// ResetLoginFailures clears the failed attempts of an account after a
+// successful login or an admin unlock. IP counters are left to expire.
+func ResetLoginFailures(email string) error {
+	collection, ctx := GetCollection("login_attempts")
+	defer ctx.Done()
+
+	_, err := collection.DeleteOne(ctx, bson.M{"key": accountAttemptKey(email)})
+	return err
+}
+
+// recordAttemptFailure increments the counter for key and reports whether it
+// is now locked. The lockout doubles with every failure past the threshold.
+func recordAttemptFailure(key string, threshold int) bool {
+	collection, ctx := GetCollection("login_attempts")
+	defer ctx.Done()
+
+	now := time.Now()
+
+	// Start over once the previous counter has expired
+	collection.DeleteOne(ctx, bson.M{"key": key, "expires_at": bson.M{"$lte": primitive.NewDateTimeFromTime(now)}})
+
+	var attempt models.LoginAttempt
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"key": key},
+		bson.M{
+			"$inc": bson.M{"failures": 1},
+			"$set": bson.M{
+				"last_failed_at": primitive.NewDateTimeFromTime(now),
+				"expires_at":     primitive.NewDateTimeFromTime(now.Add(AppConfig.LoginAttemptWindow)),
+			},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&attempt)
+	if err != nil {
+		log.Printf("Failed to record login failure for %s: %v", key, err)
+		return false
+	}
+
+	if threshold <= 0 || attempt.Failures < threshold {
+		return false
+	}
+
+	lockedUntil := now.Add(lockoutDuration(attempt.Failures - threshold))
+	expiresAt := now.Add(AppConfig.LoginAttemptWindow)
+	if lockedUntil.After(expiresAt) {
+		expiresAt = lockedUntil
+	}
+	collection.UpdateOne(ctx, bson.M{"_id": attempt.ID}, bson.M{"$set": bson.M{
+		"locked_until": primitive.NewDateTimeFromTime(lockedUntil),
+		"expires_at":   primitive.NewDateTimeFromTime(expiresAt),
+	}})
+	return true
+}
+
+// lockoutDuration returns the base lockout doubled for each extra failure,
+// capped at the configured maximum
+func lockoutDuration(extraFailures int) time.Duration {
+	lockout := AppConfig.LoginLockoutBase
+	for i := 0; i < extraFailures && lockout < AppConfig.LoginLockoutMax; i++ {
+		lockout *= 2
+	}
+	if lockout > AppConfig.LoginLockoutMax {
+		lockout = AppConfig.LoginLockoutMax
+	}
+	return lockout
+}
+
+// RecordAuthEvent appends an entry to the authentication event log
+func RecordAuthEvent(c *gin.Context, event models.AuthEvent) {
+	collection, ctx := GetCollection("auth_events")
+	defer ctx.Done()
+
+	event.ID = primitive.NewObjectID()
+	event.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	if c != nil {
+		event.IP = c.ClientIP()
+		event.UserAgent = c.Request.UserAgent()
+	}
+	if _, err := collection.InsertOne(ctx, event); err != nil {
+		log.Printf("Failed to record auth event %s: %v", event.Type, err)
+	}
+}
+
+// ListAuthEvents returns the most recent authentication events of a user
+func ListAuthEvents(userID primitive.ObjectID, limit int64) ([]models.AuthEvent, error) {
+	collection, ctx := GetCollection("auth_events")
+	defer ctx.Done()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []models.AuthEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -1,12 +1,14 @@
 package common
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func ConvertIDMongodb(_id string, c *gin.Context) (primitive.ObjectID, error) {
@@ -18,6 +20,45 @@ func ConvertIDMongodb(_id string, c *gin.Context) (primitive.ObjectID, error) {
 	return objectID, nil
 }
 
+// GetUserIDFromContext retrieves the authenticated user ID from the request context
+func GetUserIDFromContext(c *gin.Context) (primitive.ObjectID, error) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		return primitive.NilObjectID, fmt.Errorf("user ID not found in context")
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		return primitive.NilObjectID, fmt.Errorf("user ID is not a valid string")
+	}
+
+	return primitive.ObjectIDFromHex(userIDStr)
+}
+
+// Actor identifies the authenticated user behind a change
+type Actor struct {
+	ID   primitive.ObjectID
+	Role string
+}
+
+// ActorFromContext builds the actor from the authenticated request. Changes
+// made with an API key are attributed to the key.
+func ActorFromContext(c *gin.Context) (Actor, error) {
+	role, _ := c.Get("role")
+	roleStr, _ := role.(string)
+
+	if apiKeyID := c.GetString("apiKeyID"); apiKeyID != "" {
+		keyID, err := primitive.ObjectIDFromHex(apiKeyID)
+		return Actor{ID: keyID, Role: roleStr}, err
+	}
+
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return Actor{}, err
+	}
+	return Actor{ID: userID, Role: roleStr}, nil
+}
+
 // respondWithError sends an error response
 func RespondWithError(c *gin.Context, statusCode int, message string, err error) {
 	if err != nil {
@@ -60,7 +101,7 @@ func DeleteOneCommonByID(objectID primitive.ObjectID, c *gin.Context, collection
 
 	if result.DeletedCount == 0 {
 		RespondWithError(c, http.StatusNotFound, collectionDB+" not found", nil)
-		return err
+		return mongo.ErrNoDocuments
 	}
 
 	return nil
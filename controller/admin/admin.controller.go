@@ -0,0 +1,85 @@
+package adminController
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Impersonate issues a short-lived token letting support staff act as a customer
+func Impersonate(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("userId"), c)
+	if err != nil {
+		return
+	}
+
+	actor, err := common.ActorFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	// Impersonation tokens can't be chained
+	if c.GetString("impersonatorID") != "" {
+		common.RespondWithError(c, http.StatusForbidden, "This action is not allowed while impersonating a user", nil)
+		return
+	}
+
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	var user models.User
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user); err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "User not found", err)
+		return
+	}
+
+	// Only customers can be impersonated, never staff or oneself
+	if user.Role != models.RoleUser || user.ID == actor.ID {
+		common.RespondWithError(c, http.StatusForbidden, "This user cannot be impersonated", nil)
+		return
+	}
+
+	token, err := common.StartImpersonation(user, actor.ID, c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to start impersonation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Impersonating " + user.Email, "impersonation": token, "status_code": http.StatusOK})
+}
+
+// EndImpersonation revokes an impersonation session before it expires
+func EndImpersonation(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	actor, err := common.ActorFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	collection, ctx := common.GetCollection("sessions")
+	defer ctx.Done()
+
+	var session models.Session
+	err = collection.FindOne(ctx, bson.M{"_id": objectID, "impersonator_id": bson.M{"$exists": true}}).Decode(&session)
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "Impersonation session not found", err)
+		return
+	}
+
+	if err := common.RevokeSession(session.ID, models.SessionRevokedImpersonation); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to end impersonation", err)
+		return
+	}
+	common.RecordAuditLog(c, models.AuditLog{Action: models.AuditImpersonationEnded, ActorID: actor.ID, UserID: session.UserID, SessionID: session.ID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Impersonation ended", "status_code": http.StatusOK})
+}
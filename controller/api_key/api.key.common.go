@@ -0,0 +1,65 @@
+package apiKeyController
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// validateScopes checks that scopes are known permissions an API key can
+// hold; keys belong to no user, so self-scoped permissions make no sense.
+// A key can't get more access than its creator currently has.
+func validateScopes(scopes []string, c *gin.Context) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !models.IsValidPermission(scope) {
+			return fmt.Errorf("unknown scope: %s", scope)
+		}
+		if models.IsSelfScopedPermission(scope) {
+			return fmt.Errorf("scope %s only applies to user accounts", scope)
+		}
+		if slices.Contains(models.APIKeyBlockedScopes, scope) {
+			return fmt.Errorf("scope %s can't be granted to API keys", scope)
+		}
+		if !common.HasPermission(c, scope) {
+			return fmt.Errorf("scope %s is not one of your permissions", scope)
+		}
+	}
+	return nil
+}
+
+// toAPIKeyResponse converts an API key for output
+func toAPIKeyResponse(apiKey models.APIKey) APIKeyResponse {
+	allowedIPs := apiKey.AllowedIPs
+	if allowedIPs == nil {
+		allowedIPs = []string{}
+	}
+	return APIKeyResponse{
+		ID:         apiKey.ID.Hex(),
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.Scopes,
+		AllowedIPs: allowedIPs,
+		CreatedBy:  apiKey.CreatedBy.Hex(),
+		CreatedAt:  formatDateTime(apiKey.CreatedAt),
+		ExpiresAt:  formatDateTime(apiKey.ExpiresAt),
+		LastUsedAt: formatDateTime(apiKey.LastUsedAt),
+		LastUsedIP: apiKey.LastUsedIP,
+		RevokedAt:  formatDateTime(apiKey.RevokedAt),
+	}
+}
+
+// formatDateTime formats a timestamp as RFC 3339, or empty when unset
+func formatDateTime(value primitive.DateTime) string {
+	if value == 0 {
+		return ""
+	}
+	return value.Time().Format(time.RFC3339)
+}
@@ -0,0 +1,122 @@
+package apiKeyController
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetAPIKeys lists all API keys
+func GetAPIKeys(c *gin.Context) {
+	collection, ctx := common.GetCollection("api_keys")
+	defer ctx.Done()
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch API keys", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var apiKeys []models.APIKey
+	if err := cursor.All(ctx, &apiKeys); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to decode API keys", err)
+		return
+	}
+
+	response := make([]APIKeyResponse, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		response = append(response, toAPIKeyResponse(apiKey))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": response, "status_code": http.StatusOK})
+}
+
+// AddAPIKey creates an API key. The key is only returned in this response.
+func AddAPIKey(c *gin.Context) {
+	var requestBody RequestCreateAPIKey
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := validateScopes(requestBody.Scopes, c); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid scopes", err)
+		return
+	}
+	if err := common.ValidateAllowedIPs(requestBody.AllowedIPs); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid IP allowlist", err)
+		return
+	}
+	if requestBody.ExpiresAt != nil && requestBody.ExpiresAt.Before(time.Now()) {
+		common.RespondWithError(c, http.StatusBadRequest, "Expiry must be in the future", nil)
+		return
+	}
+
+	actor, err := common.ActorFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	key, prefix, hash, err := common.GenerateAPIKey()
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to generate API key", err)
+		return
+	}
+
+	apiKey := models.APIKey{
+		ID:         primitive.NewObjectID(),
+		Name:       requestBody.Name,
+		Prefix:     prefix,
+		KeyHash:    hash,
+		Scopes:     requestBody.Scopes,
+		AllowedIPs: requestBody.AllowedIPs,
+		CreatedBy:  actor.ID,
+		CreatedAt:  primitive.NewDateTimeFromTime(time.Now()),
+	}
+	if requestBody.ExpiresAt != nil {
+		apiKey.ExpiresAt = primitive.NewDateTimeFromTime(*requestBody.ExpiresAt)
+	}
+
+	collection, ctx := common.GetCollection("api_keys")
+	defer ctx.Done()
+
+	if _, err := collection.InsertOne(ctx, apiKey); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to add API key", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key created; store it now, it won't be shown again", "key": key, "api_key": toAPIKeyResponse(apiKey), "status_code": http.StatusOK})
+}
+
+// RevokeAPIKey stops an API key from working
+func RevokeAPIKey(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	collection, ctx := common.GetCollection("api_keys")
+	defer ctx.Done()
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": objectID, "revoked_at": bson.M{"$exists": false}}, bson.M{
+		"$set": bson.M{"revoked_at": primitive.NewDateTimeFromTime(time.Now())},
+	})
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to revoke API key", err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		common.RespondWithError(c, http.StatusNotFound, "API key not found", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully", "status_code": http.StatusOK})
+}
@@ -0,0 +1,393 @@
+package authController
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// normalizeEmail lower-cases and trims an email address
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// findUserByEmail retrieves a user by email address. Registered emails are
+// stored normalized; older accounts as entered, so both forms are looked up.
+func findUserByEmail(email string) (models.User, error) {
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"email": bson.M{"$in": []string{strings.TrimSpace(email), normalizeEmail(email)}}}).Decode(&user)
+	return user, err
+}
+
+// sendVerificationEmail mails the user a signed link to verify their email
+func sendVerificationEmail(user models.User) error {
+	token, err := common.GeneratePurposeToken(user.ID.Hex(), common.PurposeEmailVerification, user.Email, common.AppConfig.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/verify-email?token=%s", strings.TrimRight(common.AppConfig.AppBaseURL, "/"), url.QueryEscape(token))
+
+	return common.AppMailer.Send(common.Email{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease verify your email address by opening the link below:\n\n%s\n\nThe link expires in %s.",
+			user.Name, link, common.AppConfig.EmailVerificationTTL),
+	})
+}
+
+// createPasswordReset replaces any outstanding reset tokens of the user with
+// a new one and mails it to them
+func createPasswordReset(user models.User) error {
+	token, err := common.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	collection, ctx := common.GetCollection("password_resets")
+	defer ctx.Done()
+
+	// Only the most recent link works
+	if _, err := collection.DeleteMany(ctx, bson.M{"user_id": user.ID, "used_at": bson.M{"$exists": false}}); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	reset := models.PasswordReset{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		TokenHash: common.HashToken(token),
+		ExpiresAt: primitive.NewDateTimeFromTime(now.Add(common.AppConfig.PasswordResetTTL)),
+		CreatedAt: primitive.NewDateTimeFromTime(now),
+	}
+	if _, err := collection.InsertOne(ctx, reset); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(common.AppConfig.AppBaseURL, "/"), url.QueryEscape(token))
+
+	return common.AppMailer.Send(common.Email{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password:\n\n%s\n\nThe link expires in %s and can be used once. If you didn't ask for this, you can ignore this email.",
+			user.Name, link, common.AppConfig.PasswordResetTTL),
+	})
+}
+
+// redeemPasswordReset marks an unused, unexpired reset token as used and
+// returns the user it belongs to
+func redeemPasswordReset(token string) (primitive.ObjectID, error) {
+	collection, ctx := common.GetCollection("password_resets")
+	defer ctx.Done()
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+
+	var reset models.PasswordReset
+	err := collection.FindOneAndUpdate(ctx, bson.M{
+		"token_hash": common.HashToken(token),
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}, bson.M{"$set": bson.M{"used_at": now}}).Decode(&reset)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("invalid or expired reset token")
+	}
+	return reset.UserID, nil
+}
+
+// setPassword hashes and stores a new password for the user
+func setPassword(userID primitive.ObjectID, password string, extra bson.M) error {
+	hashedPassword, err := common.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"password": hashedPassword}}
+	for key, value := range extra {
+		update[key] = value
+	}
+
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": userID}, update)
+	return err
+}
+
+// twoFactorChallengeTTL is how long a user has to complete a 2FA login
+const twoFactorChallengeTTL = 5 * time.Minute
+
+// recoveryCodeCount is the number of recovery codes issued at a time
+const recoveryCodeCount = 10
+
+// requiresTwoFactor reports whether the user must pass a second factor to log in
+func requiresTwoFactor(user models.User) bool {
+	return user.TOTPEnabled || (user.Role == models.RoleAdmin && common.AppConfig.RequireAdmin2FA)
+}
+
+// respondWithTwoFactorChallenge sends the token a login must be completed with
+func respondWithTwoFactorChallenge(c *gin.Context, user models.User) {
+	challengeToken, err := common.GeneratePurposeToken(user.ID.Hex(), common.PurposeTwoFactorChallenge, user.Email, twoFactorChallengeTTL)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to generate token", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"two_factor_required": true, "setup_required": !user.TOTPEnabled, "challenge_token": challengeToken, "status_code": http.StatusOK})
+}
+
+// respondWithSession starts a session for the user and sends its tokens.
+// Only a completed login clears the account's failed attempts; a correct
+// password alone must not reset the lockout that also guards 2FA codes.
+func respondWithSession(c *gin.Context, user models.User, extra gin.H) {
+	tokens, err := common.CreateSession(user, c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to generate token", err)
+		return
+	}
+
+	common.RecordAuthEvent(c, models.AuthEvent{Type: models.AuthEventLoginSucceeded, UserID: user.ID, Email: user.Email})
+	common.ResetLoginFailures(user.Email)
+
+	response := gin.H{"user": user.ToResponse(), "token": tokens.AccessToken, "refresh_token": tokens.RefreshToken, "expires_in": tokens.ExpiresIn, "session_id": tokens.SessionID, "status_code": http.StatusOK}
+	for key, value := range extra {
+		response[key] = value
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// findUserByID retrieves a user by ID
+func findUserByID(userID primitive.ObjectID) (models.User, error) {
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	return user, err
+}
+
+// startTOTPEnrollment stores a new pending secret for the user and returns
+// it with its provisioning URI
+func startTOTPEnrollment(user models.User) (string, string, error) {
+	secret, err := common.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"totp_pending_secret": secret}}); err != nil {
+		return "", "", err
+	}
+
+	return secret, common.TOTPProvisioningURI(common.AppConfig.TOTPIssuer, user.Email, secret), nil
+}
+
+// confirmTOTPEnrollment enables 2FA once the user proves their authenticator
+// works with the pending secret, and returns fresh recovery codes
+func confirmTOTPEnrollment(user models.User, code string) ([]string, error) {
+	if user.TOTPPendingSecret == "" {
+		return nil, fmt.Errorf("two-factor setup has not been started")
+	}
+
+	step, ok := common.ValidateTOTP(user.TOTPPendingSecret, code, time.Now())
+	if !ok {
+		return nil, fmt.Errorf("invalid authentication code")
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": user.ID, "totp_pending_secret": user.TOTPPendingSecret}, bson.M{
+		"$set": bson.M{
+			"totp_enabled":         true,
+			"totp_secret":          user.TOTPPendingSecret,
+			"totp_last_step":       step,
+			"recovery_code_hashes": hashes,
+		},
+		"$unset": bson.M{"totp_pending_secret": ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		// Setup was restarted or confirmed by another request meanwhile
+		return nil, fmt.Errorf("two-factor setup has changed, please start again")
+	}
+	return codes, nil
+}
+
+// verifySecondFactor checks an authenticator code, or consumes a recovery code
+func verifySecondFactor(user models.User, code string, recoveryCode string) error {
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	if recoveryCode != "" {
+		hash := common.HashToken(strings.ToLower(strings.TrimSpace(recoveryCode)))
+		result, err := collection.UpdateOne(ctx,
+			bson.M{"_id": user.ID, "recovery_code_hashes": hash},
+			bson.M{"$pull": bson.M{"recovery_code_hashes": hash}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			return fmt.Errorf("invalid recovery code")
+		}
+		return nil
+	}
+
+	step, ok := common.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return fmt.Errorf("invalid authentication code")
+	}
+
+	// Each code may only be used once
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "totp_last_step": bson.M{"$not": bson.M{"$gte": step}}},
+		bson.M{"$set": bson.M{"totp_last_step": step}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return fmt.Errorf("authentication code has already been used")
+	}
+	return nil
+}
+
+// newRecoveryCodes generates recovery codes and their hashes for storage
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := common.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, common.HashToken(code))
+	}
+	return codes, hashes, nil
+}
+
+// dummyPasswordHash is compared against when no account matches an email,
+// so failed logins take the same time whether or not the account exists
+var dummyPasswordHash, _ = common.HashPassword("not-a-real-password")
+
+// recordFailedLogin counts a failed attempt against the account and IP
+// address and logs it along with any lockout it triggered
+func recordFailedLogin(c *gin.Context, user models.User, email string, eventType string) {
+	common.RecordAuthEvent(c, models.AuthEvent{Type: eventType, UserID: user.ID, Email: email})
+
+	accountLocked, ipLocked := common.RecordLoginFailure(email, c.ClientIP())
+	if accountLocked {
+		common.RecordAuthEvent(c, models.AuthEvent{Type: models.AuthEventAccountLocked, UserID: user.ID, Email: email})
+	}
+	if ipLocked {
+		common.RecordAuthEvent(c, models.AuthEvent{Type: models.AuthEventIPAddressLocked, Email: email})
+	}
+}
+
+// respondLoginLocked refuses a login attempt made during a lockout
+func respondLoginLocked(c *gin.Context, email string, retryAfter time.Duration, err error) {
+	if !errors.Is(err, common.ErrLoginLocked) {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to process login", err)
+		return
+	}
+
+	common.RecordAuthEvent(c, models.AuthEvent{Type: models.AuthEventLoginThrottled, Email: email})
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	common.RespondWithError(c, http.StatusTooManyRequests, "Too many failed login attempts, try again later", nil)
+}
+
+// errUnverifiedProviderEmail is returned when a new provider identity can't
+// be matched to an account because its email isn't verified
+var errUnverifiedProviderEmail = errors.New("identity provider did not return a verified email address")
+
+// findOrLinkOIDCUser returns the user linked to a provider identity. An
+// unlinked identity is linked to the account with the same email, or gets a
+// new customer account without a password. An account still awaiting email
+// verification loses its password when linked and must reset it to use it.
+func findOrLinkOIDCUser(identity common.OIDCIdentity) (models.User, error) {
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": identity.Provider, "subject": identity.Subject}}}).Decode(&user)
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return user, err
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return user, errUnverifiedProviderEmail
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	email := normalizeEmail(identity.Email)
+	link := models.UserIdentity{Provider: identity.Provider, Subject: identity.Subject, Email: email, LinkedAt: now}
+
+	var existing models.User
+	err = collection.FindOne(ctx, bson.M{"email": bson.M{"$in": []string{identity.Email, email}}}).Decode(&existing)
+	if err == nil {
+		filter := bson.M{"_id": existing.ID, "email_verification_pending": bson.M{"$ne": true}}
+		update := bson.M{"$push": bson.M{"identities": link}}
+		if existing.EmailVerificationPending {
+			// Whoever registered the address never proved they own it. The
+			// provider has, so the account is verified but the password and
+			// 2FA chosen at registration must not start working with it.
+			filter["email_verification_pending"] = true
+			update["$set"] = bson.M{"email_verified_at": now}
+			update["$unset"] = bson.M{
+				"email_verification_pending": "",
+				"password":                   "",
+				"totp_enabled":               "",
+				"totp_secret":                "",
+				"totp_pending_secret":        "",
+				"totp_last_step":             "",
+				"recovery_code_hashes":       "",
+			}
+		}
+
+		err = collection.FindOneAndUpdate(ctx, filter, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&user)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return user, fmt.Errorf("account %s changed while linking %s", existing.ID.Hex(), identity.Provider)
+		}
+		return user, err
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return user, err
+	}
+
+	user = models.User{
+		ID:              primitive.NewObjectID(),
+		Name:            identity.Name,
+		Email:           email,
+		Role:            models.RoleUser,
+		CreatedAt:       now,
+		EmailVerifiedAt: now,
+		Identities:      []models.UserIdentity{link},
+	}
+	_, err = collection.InsertOne(ctx, user)
+	return user, err
+}
@@ -1,7 +1,6 @@
 package authController
 
 import (
-	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -9,7 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/wachirawittd123/shop-online-backend-golang/common"
 	models "github.com/wachirawittd123/shop-online-backend-golang/model"
-	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Login authenticates a user and generates a JWT token
@@ -25,36 +24,40 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	collection, ctx := common.GetCollection("users")
-
-	var user models.User
-	err := collection.FindOne(ctx, bson.M{"email": loginData.Email}).Decode(&user)
-	if err != nil {
-		common.RespondWithError(c, http.StatusUnauthorized, "Invalid email or password", err)
+	// Refuse attempts while the account or this IP address is locked out
+	if retryAfter, err := common.CheckLoginAllowed(loginData.Email, c.ClientIP()); err != nil {
+		respondLoginLocked(c, loginData.Email, retryAfter, err)
 		return
 	}
 
-	// Validate password
-	if !common.ComparePasswords(user.Password, loginData.Password) {
-		common.RespondWithError(c, http.StatusUnauthorized, "Invalid password", err)
+	user, err := findUserByEmail(loginData.Email)
+
+	// Validate password. Unknown emails still pay for a bcrypt comparison and
+	// get the same answer so responses don't reveal which accounts exist.
+	hashedPassword := user.Password
+	if err != nil {
+		hashedPassword = dummyPasswordHash
+	}
+	if !common.ComparePasswords(hashedPassword, loginData.Password) || err != nil {
+		recordFailedLogin(c, user, loginData.Email, models.AuthEventLoginFailed)
+		common.RespondWithError(c, http.StatusUnauthorized, "Invalid email or password", nil)
 		return
 	}
 
-	// Generate JWT token
-	token, err := common.GenerateToken(user.ID.Hex(), user.Role)
-	log.Println(token)
-	if err != nil {
-		common.RespondWithError(c, http.StatusInternalServerError, "Failed to generate token", err)
+	// Self-registered users must verify their email first
+	if user.EmailVerificationPending {
+		common.RespondWithError(c, http.StatusForbidden, "Email address has not been verified", nil)
 		return
 	}
 
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"token": token}})
-	if err != nil {
-		common.RespondWithError(c, http.StatusInternalServerError, "Failed to update token version", err)
+	// Ask for a second factor before issuing any tokens
+	if requiresTwoFactor(user) {
+		respondWithTwoFactorChallenge(c, user)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user": user, "token": token, "status_code": http.StatusOK})
+	// Start a session for this device and issue its tokens
+	respondWithSession(c, user, nil)
 }
 
 func Logout(c *gin.Context) {
@@ -81,7 +84,15 @@ func Logout(c *gin.Context) {
 
 	// Add the token to the blacklist
 	expirationTime := time.Unix(claims.ExpiresAt, 0)
-	common.AddToBlacklist(tokenString, expirationTime)
+	if err := common.AddToBlacklist(tokenString, expirationTime); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to invalidate token", err)
+		return
+	}
+
+	// End the session so its refresh token stops working too
+	if sessionID, err := primitive.ObjectIDFromHex(claims.SessionID); err == nil {
+		common.RevokeSession(sessionID, models.SessionRevokedLogout)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully logged out", "status_code": http.StatusOK})
 }
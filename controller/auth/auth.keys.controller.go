@@ -0,0 +1,14 @@
+package authController
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+)
+
+// GetJWKS publishes the public keys tokens can be verified with
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, common.JWTKeys.JWKS())
+}
@@ -0,0 +1,127 @@
+package authController
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+)
+
+// OIDCLogin redirects the user to the identity provider to sign in
+func OIDCLogin(c *gin.Context) {
+	provider, err := common.FindOIDCProvider(c.Param("provider"))
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "Unknown identity provider", nil)
+		return
+	}
+
+	authURL, state, err := common.BeginOIDCLogin(provider)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadGateway, "Failed to start sign in with provider", err)
+		return
+	}
+
+	// Ties the login to this browser; the provider redirects back top-level, which Lax allows
+	setOIDCStateCookie(c, provider.Name, state, int(common.OIDCStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback completes a provider sign in and sends the browser to the
+// frontend with a single-use login code, or with an error
+func OIDCCallback(c *gin.Context) {
+	provider, err := common.FindOIDCProvider(c.Param("provider"))
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "Unknown identity provider", nil)
+		return
+	}
+
+	browserState, _ := c.Cookie(common.OIDCStateCookie)
+	setOIDCStateCookie(c, provider.Name, "", -1)
+
+	if providerError := c.Query("error"); providerError != "" {
+		redirectOIDCError(c, "provider_error")
+		return
+	}
+
+	identity, err := common.CompleteOIDCLogin(provider, c.Query("code"), c.Query("state"), browserState)
+	if err != nil {
+		if errors.Is(err, common.ErrInvalidOIDCState) {
+			redirectOIDCError(c, "invalid_state")
+			return
+		}
+		log.Printf("Failed to sign in with %s: %v", provider.Name, err)
+		redirectOIDCError(c, "provider_failed")
+		return
+	}
+
+	user, err := findOrLinkOIDCUser(identity)
+	if err != nil {
+		if errors.Is(err, errUnverifiedProviderEmail) {
+			redirectOIDCError(c, "unverified_email")
+			return
+		}
+		log.Printf("Failed to link %s account %s: %v", provider.Name, identity.Subject, err)
+		redirectOIDCError(c, "server_error")
+		return
+	}
+
+	code, err := common.IssueOIDCLoginCode(user.ID, provider.Name)
+	if err != nil {
+		log.Printf("Failed to issue login code for user %s: %v", user.ID.Hex(), err)
+		redirectOIDCError(c, "server_error")
+		return
+	}
+
+	c.Redirect(http.StatusFound, common.OIDCFrontendRedirect(url.Values{"code": {code}}))
+}
+
+// OIDCExchange trades the login code of a provider sign in for a session,
+// or a two-factor challenge when the account requires one
+func OIDCExchange(c *gin.Context) {
+	var requestBody RequestOIDCExchange
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	userID, err := common.RedeemOIDCLoginCode(requestBody.Code)
+	if err != nil {
+		if errors.Is(err, common.ErrInvalidOIDCLoginCode) {
+			common.RespondWithError(c, http.StatusUnauthorized, "Invalid or expired login code", nil)
+			return
+		}
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to sign in with provider", err)
+		return
+	}
+
+	user, err := findUserByID(userID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "Invalid or expired login code", nil)
+		return
+	}
+
+	if requiresTwoFactor(user) {
+		respondWithTwoFactorChallenge(c, user)
+		return
+	}
+
+	respondWithSession(c, user, nil)
+}
+
+// setOIDCStateCookie sets, or with a negative maxAge clears, the cookie
+// holding the state of a provider sign in
+func setOIDCStateCookie(c *gin.Context, provider string, state string, maxAge int) {
+	secure := strings.HasPrefix(common.AppConfig.AppBaseURL, "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(common.OIDCStateCookie, state, maxAge, "/auth/oidc/"+provider, "", secure, true)
+}
+
+// redirectOIDCError sends the browser to the frontend with the reason a
+// provider sign in failed
+func redirectOIDCError(c *gin.Context, reason string) {
+	c.Redirect(http.StatusFound, common.OIDCFrontendRedirect(url.Values{"error": {reason}}))
+}
@@ -0,0 +1,100 @@
+package authController
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ForgotPassword emails a single-use reset link. The response is the same
+// whether or not the email exists so it can't be used to probe accounts.
+func ForgotPassword(c *gin.Context) {
+	var requestBody RequestEmail
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	user, err := findUserByEmail(requestBody.Email)
+	if err == nil {
+		if err := createPasswordReset(user); err != nil {
+			log.Println("Failed to create password reset:", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the account exists, a password reset link has been sent", "status_code": http.StatusOK})
+}
+
+// ResetPassword sets a new password using a reset token and logs out every device
+func ResetPassword(c *gin.Context) {
+	var requestBody RequestResetPassword
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	userID, err := redeemPasswordReset(requestBody.Token)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid or expired reset token", nil)
+		return
+	}
+
+	// Following the emailed link proves the user owns the address
+	if err := setPassword(userID, requestBody.NewPassword, bson.M{"$unset": bson.M{"email_verification_pending": ""}}); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to reset password", err)
+		return
+	}
+
+	if err := common.RevokeUserSessions(userID, primitive.NilObjectID, models.SessionRevokedPasswordChange); err != nil {
+		log.Println("Failed to revoke sessions after password reset:", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully", "status_code": http.StatusOK})
+}
+
+// ChangePassword changes the password of the logged-in user and logs out
+// every other device
+func ChangePassword(c *gin.Context) {
+	var requestBody RequestChangePassword
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	userID, err := common.GetUserIDFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	var user models.User
+	if err := collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "User not found", err)
+		return
+	}
+
+	if !common.ComparePasswords(user.Password, requestBody.OldPassword) {
+		common.RespondWithError(c, http.StatusUnauthorized, "Old password is incorrect", nil)
+		return
+	}
+
+	if err := setPassword(userID, requestBody.NewPassword, nil); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to change password", err)
+		return
+	}
+
+	currentSessionID, _ := primitive.ObjectIDFromHex(c.GetString("sessionID"))
+	if err := common.RevokeUserSessions(userID, currentSessionID, models.SessionRevokedPasswordChange); err != nil {
+		log.Println("Failed to revoke sessions after password change:", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully", "status_code": http.StatusOK})
+}
@@ -0,0 +1,118 @@
+package authController
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Register creates a customer account and sends an email verification link
+func Register(c *gin.Context) {
+	var requestBody RequestRegister
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	email := normalizeEmail(requestBody.Email)
+	if _, err := findUserByEmail(requestBody.Email); err == nil {
+		common.RespondWithError(c, http.StatusConflict, "Email already in use", nil)
+		return
+	}
+
+	hashedPassword, err := common.HashPassword(requestBody.Password)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to hash password", err)
+		return
+	}
+
+	user := models.User{
+		ID:                       primitive.NewObjectID(),
+		Name:                     requestBody.Name,
+		Email:                    email,
+		Password:                 hashedPassword,
+		Role:                     models.RoleUser,
+		CreatedAt:                primitive.NewDateTimeFromTime(time.Now()),
+		EmailVerificationPending: true,
+	}
+
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	if _, err := collection.InsertOne(ctx, user); err != nil {
+		// Another registration took the email since the check above
+		if mongo.IsDuplicateKeyError(err) {
+			common.RespondWithError(c, http.StatusConflict, "Email already in use", nil)
+			return
+		}
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to register user", err)
+		return
+	}
+
+	if err := sendVerificationEmail(user); err != nil {
+		log.Println("Failed to send verification email:", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Registration successful, please check your email to verify your account", "status_code": http.StatusOK})
+}
+
+// VerifyEmail marks the email address of a signed verification link as verified
+func VerifyEmail(c *gin.Context) {
+	claims, err := common.ValidatePurposeToken(c.Query("token"), common.PurposeEmailVerification)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid or expired verification link", nil)
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid or expired verification link", nil)
+		return
+	}
+
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	// The link only verifies the address it was sent to
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": userID, "email": claims.Subject}, bson.M{
+		"$set":   bson.M{"email_verified_at": primitive.NewDateTimeFromTime(time.Now())},
+		"$unset": bson.M{"email_verification_pending": ""},
+	})
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to verify email", err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		// The account is gone or its email has changed since the link was sent
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid or expired verification link", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully", "status_code": http.StatusOK})
+}
+
+// ResendVerification sends a new verification link. The response is the same
+// whether or not the email exists so it can't be used to probe accounts.
+func ResendVerification(c *gin.Context) {
+	var requestBody RequestEmail
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	user, err := findUserByEmail(requestBody.Email)
+	if err == nil && user.EmailVerificationPending {
+		if err := sendVerificationEmail(user); err != nil {
+			log.Println("Failed to send verification email:", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the account exists and is unverified, a new link has been sent", "status_code": http.StatusOK})
+}
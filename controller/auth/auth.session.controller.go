@@ -0,0 +1,117 @@
+package authController
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Refresh exchanges a refresh token for a new access and refresh token
+func Refresh(c *gin.Context) {
+	var requestBody RequestRefreshToken
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	tokens, err := common.RotateSession(requestBody.RefreshToken, c)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrRefreshTokenReuse):
+			common.RespondWithError(c, http.StatusUnauthorized, "Refresh token has already been used; session revoked", nil)
+		case errors.Is(err, common.ErrInvalidRefreshToken):
+			common.RespondWithError(c, http.StatusUnauthorized, "Invalid or expired refresh token", nil)
+		default:
+			common.RespondWithError(c, http.StatusInternalServerError, "Failed to refresh token", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": tokens.AccessToken, "refresh_token": tokens.RefreshToken, "expires_in": tokens.ExpiresIn, "session_id": tokens.SessionID, "status_code": http.StatusOK})
+}
+
+// GetSessions lists the devices the user is logged in on
+func GetSessions(c *gin.Context) {
+	userID, err := common.GetUserIDFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+	currentSessionID := c.GetString("sessionID")
+
+	sessions, err := common.ListActiveSessions(userID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch sessions", err)
+		return
+	}
+
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, SessionResponse{
+			ID:           session.ID.Hex(),
+			UserAgent:    session.UserAgent,
+			IP:           session.IP,
+			CreatedAt:    session.CreatedAt.Time().Format(time.RFC3339),
+			LastSeenAt:   session.LastSeenAt.Time().Format(time.RFC3339),
+			Current:      session.ID.Hex() == currentSessionID,
+			Impersonated: !session.ImpersonatorID.IsZero(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": response, "status_code": http.StatusOK})
+}
+
+// RevokeSession logs out one of the user's devices
+func RevokeSession(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	userID, err := common.GetUserIDFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	sessions, err := common.ListActiveSessions(userID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch sessions", err)
+		return
+	}
+
+	for _, session := range sessions {
+		if session.ID == objectID {
+			if err := common.RevokeSession(objectID, models.SessionRevokedByUser); err != nil {
+				common.RespondWithError(c, http.StatusInternalServerError, "Failed to revoke session", err)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully", "status_code": http.StatusOK})
+			return
+		}
+	}
+
+	common.RespondWithError(c, http.StatusNotFound, "Session not found", nil)
+}
+
+// RevokeOtherSessions logs out every device except the current one
+func RevokeOtherSessions(c *gin.Context) {
+	userID, err := common.GetUserIDFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	currentSessionID, _ := primitive.ObjectIDFromHex(c.GetString("sessionID"))
+	if err := common.RevokeUserSessions(userID, currentSessionID, models.SessionRevokedByUser); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to revoke sessions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked successfully", "status_code": http.StatusOK})
+}
@@ -0,0 +1,64 @@
+package authController
+
+type RequestRefreshToken struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// SessionResponse describes a logged-in device
+type SessionResponse struct {
+	ID           string `json:"id"`
+	UserAgent    string `json:"user_agent"`
+	IP           string `json:"ip"`
+	CreatedAt    string `json:"created_at"`
+	LastSeenAt   string `json:"last_seen_at"`
+	Current      bool   `json:"current"`      // Whether this is the session making the request
+	Impersonated bool   `json:"impersonated"` // Whether support staff opened the session
+}
+
+// RequestRegister defines the self-service registration body; the role is
+// never taken from the request
+type RequestRegister struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type RequestEmail struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type RequestResetPassword struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+type RequestChangePassword struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+type RequestTwoFactorCode struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RequestDisableTwoFactor struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type RequestChallenge struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+// RequestLoginTwoFactor completes a login challenge with either an
+// authenticator code or a recovery code
+type RequestLoginTwoFactor struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// RequestOIDCExchange carries the login code a provider sign in ended with
+type RequestOIDCExchange struct {
+	Code string `json:"code" binding:"required"`
+}
@@ -0,0 +1,238 @@
+package authController
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SetupTwoFactor starts TOTP enrollment for the logged-in user
+func SetupTwoFactor(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.TOTPEnabled {
+		common.RespondWithError(c, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+
+	secret, uri, err := startTOTPEnrollment(user)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to start two-factor setup", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"secret": secret, "provisioning_uri": uri, "status_code": http.StatusOK})
+}
+
+// EnableTwoFactor confirms TOTP enrollment with a code from the authenticator
+func EnableTwoFactor(c *gin.Context) {
+	var requestBody RequestTwoFactorCode
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := confirmTOTPEnrollment(user, requestBody.Code)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Failed to enable two-factor authentication", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled", "recovery_codes": recoveryCodes, "status_code": http.StatusOK})
+}
+
+// DisableTwoFactor turns off TOTP after checking the password and a current code
+func DisableTwoFactor(c *gin.Context) {
+	var requestBody RequestDisableTwoFactor
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if !user.TOTPEnabled {
+		common.RespondWithError(c, http.StatusConflict, "Two-factor authentication is not enabled", nil)
+		return
+	}
+	if user.Role == models.RoleAdmin && common.AppConfig.RequireAdmin2FA {
+		common.RespondWithError(c, http.StatusForbidden, "Two-factor authentication is mandatory for admins", nil)
+		return
+	}
+	if !common.ComparePasswords(user.Password, requestBody.Password) {
+		common.RespondWithError(c, http.StatusUnauthorized, "Invalid password", nil)
+		return
+	}
+	if err := verifySecondFactor(user, requestBody.Code, ""); err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "Invalid authentication code", err)
+		return
+	}
+
+	update := bson.M{"$unset": bson.M{
+		"totp_enabled":         "",
+		"totp_secret":          "",
+		"totp_pending_secret":  "",
+		"totp_last_step":       "",
+		"recovery_code_hashes": "",
+	}}
+	if err := common.UpdateOneCommonInDB(user.ID, update, c, "users"); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled", "status_code": http.StatusOK})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var requestBody RequestTwoFactorCode
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if !user.TOTPEnabled {
+		common.RespondWithError(c, http.StatusConflict, "Two-factor authentication is not enabled", nil)
+		return
+	}
+	if err := verifySecondFactor(user, requestBody.Code, ""); err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "Invalid authentication code", err)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to generate recovery codes", err)
+		return
+	}
+	if err := common.UpdateOneCommonInDB(user.ID, bson.M{"$set": bson.M{"recovery_code_hashes": hashes}}, c, "users"); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes, "status_code": http.StatusOK})
+}
+
+// LoginSetupTwoFactor starts enrollment for a user whose login is blocked
+// until they set up two-factor authentication
+func LoginSetupTwoFactor(c *gin.Context) {
+	var requestBody RequestChallenge
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	user, ok := challengeUser(c, requestBody.ChallengeToken)
+	if !ok {
+		return
+	}
+
+	if user.TOTPEnabled {
+		common.RespondWithError(c, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+
+	secret, uri, err := startTOTPEnrollment(user)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to start two-factor setup", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"secret": secret, "provisioning_uri": uri, "status_code": http.StatusOK})
+}
+
+// LoginTwoFactor completes a login challenge. Users still enrolling confirm
+// their authenticator here and receive their recovery codes.
+func LoginTwoFactor(c *gin.Context) {
+	var requestBody RequestLoginTwoFactor
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	user, ok := challengeUser(c, requestBody.ChallengeToken)
+	if !ok {
+		return
+	}
+
+	// Codes are guessable, so they share the password lockout
+	if retryAfter, err := common.CheckLoginAllowed(user.Email, c.ClientIP()); err != nil {
+		respondLoginLocked(c, user.Email, retryAfter, err)
+		return
+	}
+
+	if !user.TOTPEnabled {
+		recoveryCodes, err := confirmTOTPEnrollment(user, requestBody.Code)
+		if err != nil {
+			recordFailedLogin(c, user, user.Email, models.AuthEventTwoFactorFailed)
+			common.RespondWithError(c, http.StatusUnauthorized, "Invalid authentication code", err)
+			return
+		}
+		respondWithSession(c, user, gin.H{"recovery_codes": recoveryCodes})
+		return
+	}
+
+	if err := verifySecondFactor(user, requestBody.Code, requestBody.RecoveryCode); err != nil {
+		recordFailedLogin(c, user, user.Email, models.AuthEventTwoFactorFailed)
+		common.RespondWithError(c, http.StatusUnauthorized, "Invalid authentication code", err)
+		return
+	}
+
+	respondWithSession(c, user, nil)
+}
+
+// currentUser loads the logged-in user
+func currentUser(c *gin.Context) (models.User, bool) {
+	userID, err := common.GetUserIDFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return models.User{}, false
+	}
+
+	user, err := findUserByID(userID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "User not found", err)
+		return user, false
+	}
+	return user, true
+}
+
+// challengeUser loads the user a login challenge token was issued to
+func challengeUser(c *gin.Context, challengeToken string) (models.User, bool) {
+	claims, err := common.ValidatePurposeToken(challengeToken, common.PurposeTwoFactorChallenge)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "Invalid or expired challenge token", nil)
+		return models.User{}, false
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "Invalid or expired challenge token", nil)
+		return models.User{}, false
+	}
+
+	user, err := findUserByID(userID)
+	if err != nil || user.Email != claims.Subject {
+		common.RespondWithError(c, http.StatusUnauthorized, "Invalid or expired challenge token", nil)
+		return user, false
+	}
+	return user, true
+}
@@ -1,6 +1,7 @@
 package cartController
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -12,6 +13,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// errCartNotActive is returned when a requested cart isn't the user's active cart
+var errCartNotActive = errors.New("cart is not active")
+
 func pilineQuery(baseMatch bson.D, search string) mongo.Pipeline {
 	pipeline := mongo.Pipeline{}
 
@@ -40,13 +44,30 @@ func pilineQuery(baseMatch bson.D, search string) mongo.Pipeline {
 		{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$product_details"}, {Key: "preserveNullAndEmptyArrays", Value: true}}},
 	})
 
-	// Add search filter for product name
+	// Pick the chosen variant out of the product's variants
+	pipeline = append(pipeline, bson.D{
+		{Key: "$addFields", Value: bson.D{
+			{Key: "variant_details", Value: bson.D{{Key: "$arrayElemAt", Value: bson.A{
+				bson.D{{Key: "$filter", Value: bson.D{
+					{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$product_details.variants", bson.A{}}}}},
+					{Key: "as", Value: "variant"},
+					{Key: "cond", Value: bson.D{{Key: "$eq", Value: bson.A{"$$variant._id", "$items.variant_id"}}}},
+				}}},
+				0,
+			}}}},
+		}},
+	})
+
+	// Add search filter for product name or variant SKU
 	if search != "" {
 		pipeline = append(pipeline, bson.D{
 			{Key: "$match", Value: bson.D{
-				{Key: "product_details.name", Value: bson.M{
-					"$regex":   search,
-					"$options": "i",
+				{Key: "$or", Value: bson.A{
+					bson.D{{Key: "product_details.name", Value: bson.M{
+						"$regex":   search,
+						"$options": "i",
+					}}},
+					bson.D{{Key: "variant_details.sku", Value: search}},
 				}},
 			}},
 		})
@@ -61,13 +82,23 @@ func pilineQuery(baseMatch bson.D, search string) mongo.Pipeline {
 			{Key: "sub_total", Value: bson.D{{Key: "$first", Value: "$sub_total"}}},
 			{Key: "total", Value: bson.D{{Key: "$first", Value: "$total"}}},
 			{Key: "delivery_fee", Value: bson.D{{Key: "$first", Value: "$delivery_fee"}}},
+			{Key: "tax", Value: bson.D{{Key: "$first", Value: "$tax"}}},
+			{Key: "delivery_tax", Value: bson.D{{Key: "$first", Value: "$delivery_tax"}}},
+			{Key: "tax_lines", Value: bson.D{{Key: "$first", Value: "$tax_lines"}}},
+			{Key: "prices_include_tax", Value: bson.D{{Key: "$first", Value: "$prices_include_tax"}}},
 			{Key: "created_at", Value: bson.D{{Key: "$first", Value: "$created_at"}}},
 			{Key: "updated_on", Value: bson.D{{Key: "$first", Value: "$updated_on"}}},
 			{Key: "items", Value: bson.D{{Key: "$push", Value: bson.D{
 				{Key: "product_id", Value: "$items.product_id"},
+				{Key: "variant_id", Value: "$items.variant_id"},
 				{Key: "qty", Value: "$items.qty"},
 				{Key: "total", Value: "$items.total"},
+				{Key: "tax_class", Value: "$items.tax_class"},
+				{Key: "tax_rate_basis_points", Value: "$items.tax_rate_basis_points"},
+				{Key: "net", Value: "$items.net"},
+				{Key: "tax", Value: "$items.tax"},
 				{Key: "product_details", Value: "$product_details"},
+				{Key: "variant_details", Value: "$variant_details"},
 			}}}},
 		}},
 	})
@@ -75,6 +106,50 @@ func pilineQuery(baseMatch bson.D, search string) mongo.Pipeline {
 	return pipeline
 }
 
+// addDisplayAmounts adds the cart totals, item totals and unit prices
+// converted with the exchange rate as display_* fields. They are for display
+// only; orders are charged in the store currency.
+func addDisplayAmounts(cart bson.M, rate models.ExchangeRate) error {
+	for _, field := range []string{"sub_total", "delivery_fee", "tax", "delivery_tax", "total"} {
+		amount, ok, err := common.ConvertStoredAmount(cart[field], rate)
+		if err != nil {
+			return err
+		}
+		if ok {
+			cart["display_"+field] = amount
+		}
+	}
+
+	items, _ := cart["items"].(bson.A)
+	for _, element := range items {
+		item, ok := element.(bson.M)
+		if !ok {
+			continue
+		}
+		amount, ok, err := common.ConvertStoredAmount(item["total"], rate)
+		if err != nil {
+			return err
+		}
+		if ok {
+			item["display_total"] = amount
+		}
+
+		// The variant's price applies when one was chosen
+		details, _ := item["variant_details"].(bson.M)
+		if details == nil {
+			details, _ = item["product_details"].(bson.M)
+		}
+		amount, ok, err = common.ConvertStoredAmount(details["price"], rate)
+		if err != nil {
+			return err
+		}
+		if ok {
+			item["display_unit_price"] = amount
+		}
+	}
+	return nil
+}
+
 func buildMatchStage(args RequestBuildMatchStage) (bson.D, error) {
 	filter := bson.D{}
 
@@ -87,6 +162,11 @@ func buildMatchStage(args RequestBuildMatchStage) (bson.D, error) {
 		filter = append(filter, bson.E{Key: "_id", Value: objectCartID})
 	}
 
+	// Filter by owner if provided
+	if !args.UserID.IsZero() {
+		filter = append(filter, bson.E{Key: "user_id", Value: args.UserID})
+	}
+
 	// Return an empty filter if no conditions are added
 	if len(filter) == 0 {
 		return bson.D{}, nil
@@ -95,39 +175,51 @@ func buildMatchStage(args RequestBuildMatchStage) (bson.D, error) {
 	return filter, nil
 }
 
-// getUserIDFromContext retrieves the user ID from the request context
-func getUserIDFromContext(c *gin.Context) (primitive.ObjectID, error) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		return primitive.NilObjectID, fmt.Errorf("user ID not found in context")
-	}
+// getCartID retrieves the active cart ID for the user, checking that a
+// requested cart ID refers to that active cart
+func getCartID(userID primitive.ObjectID, requestCartID string) (string, error) {
+	collection, ctx := common.GetCollection("carts")
+	defer ctx.Done()
 
-	userIDStr, ok := userID.(string)
-	if !ok {
-		return primitive.NilObjectID, fmt.Errorf("user ID is not a valid string")
+	var existingCart models.Cart
+	err := collection.FindOne(ctx, bson.M{"user_id": userID, "status": models.CartStatusActive}).Decode(&existingCart)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if requestCartID != "" {
+			return "", errCartNotActive
+		}
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch active cart: %v", err)
+	}
+	if requestCartID != "" && requestCartID != existingCart.ID.Hex() {
+		return "", errCartNotActive
 	}
+	return existingCart.ID.Hex(), nil
+}
+
+// findShippingAddress retrieves the shipping address of the user
+func findShippingAddress(userID primitive.ObjectID) (models.ShippingAddress, error) {
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
 
-	return common.ConvertIDMongodb(userIDStr, c)
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	return user.ShippingAddr, err
 }
 
-// getCartID retrieves the cart ID from the request or the active cart for the user
-func getCartID(userID primitive.ObjectID, requestCartID string) string {
+// findActiveCart retrieves the active cart of the user
+func findActiveCart(userID primitive.ObjectID) (models.Cart, error) {
 	collection, ctx := common.GetCollection("carts")
 	defer ctx.Done()
 
-	var existingCart models.Cart
-	collection.FindOne(ctx, bson.M{"user_id": userID, "status": "active"}).Decode(&existingCart)
-
-	if requestCartID != "" {
-		return requestCartID
-	} else if existingCart.ID.Hex() != "000000000000000000000000" {
-		return existingCart.ID.Hex()
-	}
-	return ""
+	var cart models.Cart
+	err := collection.FindOne(ctx, bson.M{"user_id": userID, "status": models.CartStatusActive}).Decode(&cart)
+	return cart, err
 }
 
 // updateCart updates an existing cart in the database
-func updateCart(cartID string, requestBody RequestUpdateCart, cartItems []models.CartItem, c *gin.Context) (error, primitive.ObjectID) {
+func updateCart(cartID string, pricing common.PriceBreakdown, c *gin.Context) (error, primitive.ObjectID) {
 	objectID, err := common.ConvertIDMongodb(cartID, c)
 	if err != nil {
 		return err, objectID
@@ -135,12 +227,15 @@ func updateCart(cartID string, requestBody RequestUpdateCart, cartItems []models
 
 	update := bson.M{
 		"$set": bson.M{
-			"status":       requestBody.Status,
-			"total":        requestBody.Total,
-			"sub_total":    requestBody.SubTotal,
-			"delivery_fee": requestBody.DeliveryFee,
-			"items":        cartItems,
-			"updated_on":   primitive.NewDateTimeFromTime(time.Now()),
+			"total":              pricing.Total,
+			"sub_total":          pricing.SubTotal,
+			"delivery_fee":       pricing.DeliveryFee,
+			"tax":                pricing.Tax,
+			"delivery_tax":       pricing.DeliveryTax,
+			"tax_lines":          pricing.TaxLines,
+			"prices_include_tax": pricing.PricesIncludeTax,
+			"items":              pricing.Items,
+			"updated_on":         primitive.NewDateTimeFromTime(time.Now()),
 		},
 	}
 
@@ -148,18 +243,22 @@ func updateCart(cartID string, requestBody RequestUpdateCart, cartItems []models
 }
 
 // createCart creates a new cart in the database
-func createCart(userID primitive.ObjectID, requestBody RequestUpdateCart, cartItems []models.CartItem) (error, primitive.ObjectID) {
+func createCart(userID primitive.ObjectID, pricing common.PriceBreakdown) (error, primitive.ObjectID) {
 	id := primitive.NewObjectID()
 	newCart := models.Cart{
-		ID:          id,
-		UserID:      userID,
-		Status:      requestBody.Status,
-		Total:       requestBody.Total,
-		SubTotal:    requestBody.SubTotal,
-		DeliveryFee: requestBody.DeliveryFee,
-		Items:       cartItems,
-		CreatedAt:   primitive.NewDateTimeFromTime(time.Now()),
-		UpdatedOn:   primitive.NewDateTimeFromTime(time.Now()),
+		ID:               id,
+		UserID:           userID,
+		Status:           models.CartStatusActive,
+		Total:            pricing.Total,
+		SubTotal:         pricing.SubTotal,
+		DeliveryFee:      pricing.DeliveryFee,
+		Tax:              pricing.Tax,
+		DeliveryTax:      pricing.DeliveryTax,
+		TaxLines:         pricing.TaxLines,
+		PricesIncludeTax: pricing.PricesIncludeTax,
+		Items:            pricing.Items,
+		CreatedAt:        primitive.NewDateTimeFromTime(time.Now()),
+		UpdatedOn:        primitive.NewDateTimeFromTime(time.Now()),
 	}
 
 	return insertCart(newCart), id
@@ -173,15 +272,51 @@ func mapRequestItemsToCartItems(items []RequestItemsCart) ([]models.CartItem, er
 		if err != nil {
 			return nil, fmt.Errorf("invalid product ID: %s", item.ProductID)
 		}
+		var variantID primitive.ObjectID
+		if item.VariantID != "" {
+			if variantID, err = primitive.ObjectIDFromHex(item.VariantID); err != nil {
+				return nil, fmt.Errorf("invalid variant ID: %s", item.VariantID)
+			}
+		}
 		cartItems = append(cartItems, models.CartItem{
 			ProductID: productID,
+			VariantID: variantID,
 			Qty:       item.Qty,
-			Total:     item.Total,
 		})
 	}
 	return cartItems, nil
 }
 
+// checkClientTotals verifies that any totals sent by the client match the server pricing
+func checkClientTotals(requestBody RequestUpdateCart, pricing common.PriceBreakdown) error {
+	if !amountMatches(requestBody.SubTotal, pricing.SubTotal) {
+		return fmt.Errorf("sub_total does not match: expected %s", pricing.SubTotal)
+	}
+	if !amountMatches(requestBody.DeliveryFee, pricing.DeliveryFee) {
+		return fmt.Errorf("delivery_fee does not match: expected %s", pricing.DeliveryFee)
+	}
+	if !amountMatches(requestBody.Tax, pricing.Tax) {
+		return fmt.Errorf("tax does not match: expected %s", pricing.Tax)
+	}
+	if !amountMatches(requestBody.Total, pricing.Total) {
+		return fmt.Errorf("total does not match: expected %s", pricing.Total)
+	}
+	for i, item := range requestBody.Items {
+		if i < len(pricing.Items) && !amountMatches(item.Total, pricing.Items[i].Total) {
+			return fmt.Errorf("total for product %s does not match: expected %s", item.ProductID, pricing.Items[i].Total)
+		}
+	}
+	return nil
+}
+
+// amountMatches reports whether an optional client amount equals the computed amount
+func amountMatches(clientAmount *models.Money, computed models.Money) bool {
+	if clientAmount == nil {
+		return true
+	}
+	return *clientAmount == computed
+}
+
 // insertCart inserts a new cart into the database
 func insertCart(cart models.Cart) error {
 	collection, ctx := common.GetCollection("carts")
@@ -1,11 +1,13 @@
 package cartController
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/wachirawittd123/shop-online-backend-golang/common"
-	deliveryController "github.com/wachirawittd123/shop-online-backend-golang/controller/delivery"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -20,6 +22,22 @@ func GetCart(c *gin.Context) {
 	if cardId != "" {
 		requestParams.CardID = cardId
 	}
+
+	// Callers without cart:read only see their own carts
+	if !common.HasPermission(c, models.PermCartRead) {
+		userID, err := common.GetUserIDFromContext(c)
+		if err != nil {
+			common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+			return
+		}
+		requestParams.UserID = userID
+	}
+	// Amounts are also shown in the requested currency, if any
+	rate, ok := common.ExchangeRateFromQuery(c)
+	if !ok {
+		return
+	}
+
 	matchStage, err := buildMatchStage(requestParams)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -46,17 +64,34 @@ func GetCart(c *gin.Context) {
 		return
 	}
 
+	response := gin.H{"status_code": http.StatusOK}
+	if rate != nil {
+		for _, cart := range cartWithProducts {
+			if err := addDisplayAmounts(cart, *rate); err != nil {
+				common.RespondWithError(c, http.StatusInternalServerError, "Failed to convert cart amounts", err)
+				return
+			}
+		}
+		response["exchange_rate"] = rate
+	}
+
 	if len(cardId) > 0 {
-		c.JSON(http.StatusOK, gin.H{"cart": cartWithProducts[0], "status_code": http.StatusOK})
+		if len(cartWithProducts) == 0 {
+			common.RespondWithError(c, http.StatusNotFound, "Cart not found", nil)
+			return
+		}
+		response["cart"] = cartWithProducts[0]
+		c.JSON(http.StatusOK, response)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"cart": cartWithProducts, "status_code": http.StatusOK})
+	response["cart"] = cartWithProducts
+	c.JSON(http.StatusOK, response)
 }
 
 func UpdateCart(c *gin.Context) {
 	// Retrieve and validate the user ID
-	userID, err := getUserIDFromContext(c)
+	userID, err := common.GetUserIDFromContext(c)
 	if err != nil {
 		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
 		return
@@ -70,7 +105,15 @@ func UpdateCart(c *gin.Context) {
 	}
 
 	// Determine the cart ID to use (new or existing)
-	newIdCart := getCartID(userID, requestBody.ID)
+	newIdCart, err := getCartID(userID, requestBody.ID)
+	if errors.Is(err, errCartNotActive) {
+		common.RespondWithError(c, http.StatusConflict, "Only the active cart can be updated", err)
+		return
+	}
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch cart", err)
+		return
+	}
 
 	// Map requestBody.Items to cart items with valid MongoDB ObjectIDs
 	cartItems, err := mapRequestItemsToCartItems(requestBody.Items)
@@ -79,18 +122,36 @@ func UpdateCart(c *gin.Context) {
 		return
 	}
 
+	shippingAddr, err := findShippingAddress(userID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "User not found", err)
+		return
+	}
+
+	// Compute prices and totals from the product catalog
+	pricing, err := common.PriceCartItems(cartItems, shippingAddr)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Failed to price cart items", err)
+		return
+	}
+
+	// Reject client totals that disagree with the server pricing
+	if err := checkClientTotals(requestBody, pricing); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"message": "Cart totals do not match current prices", "details": err.Error(), "cart": pricing, "status_code": http.StatusConflict})
+		return
+	}
+
 	var newObjectID primitive.ObjectID
 	// Handle update or create logic
 	if newIdCart != "" {
-		err, id := updateCart(newIdCart, requestBody, cartItems, c)
+		err, id := updateCart(newIdCart, pricing, c)
 		if err != nil {
 			common.RespondWithError(c, http.StatusInternalServerError, "Failed to update cart", err)
 			return
 		}
 		newObjectID = id
 	} else {
-		requestBody.Status = "active"
-		err, id := createCart(userID, requestBody, cartItems)
+		err, id := createCart(userID, pricing)
 		if err != nil {
 			common.RespondWithError(c, http.StatusInternalServerError, "Failed to create cart", err)
 			return
@@ -98,18 +159,54 @@ func UpdateCart(c *gin.Context) {
 		newObjectID = id
 	}
 
-	if requestBody.Status == "completed" {
-		deliveryRequest := deliveryController.RequestCreateDelivery{
-			OrderID:     newObjectID,             // Ensure correct type
-			UserID:      userID,                  // User ID
-			DeliveryFee: requestBody.DeliveryFee, // Delivery fee from request body
-		}
-		status := deliveryController.AddDelivery(deliveryRequest)
-		if status == 400 {
-			common.RespondWithError(c, http.StatusInternalServerError, "Failed to add delivery", err)
+	c.JSON(http.StatusOK, gin.H{"message": "Cart updated successfully", "cart_id": newObjectID.Hex(), "cart": pricing, "status_code": http.StatusOK})
+}
+
+// GetDeliveryQuote returns the delivery fee for the active cart before checkout.
+// Optional latitude/longitude query parameters override the saved address.
+func GetDeliveryQuote(c *gin.Context) {
+	userID, err := common.GetUserIDFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	shippingAddr, err := findShippingAddress(userID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "User not found", err)
+		return
+	}
+
+	if c.Query("latitude") != "" || c.Query("longitude") != "" {
+		latitude, errLat := strconv.ParseFloat(c.Query("latitude"), 64)
+		longitude, errLng := strconv.ParseFloat(c.Query("longitude"), 64)
+		if errLat != nil || errLng != nil {
+			common.RespondWithError(c, http.StatusBadRequest, "Invalid latitude or longitude", nil)
 			return
 		}
+		shippingAddr.Latitude = latitude
+		shippingAddr.Longitude = longitude
+	}
+
+	cart, err := findActiveCart(userID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "No active cart", err)
+		return
+	}
+	if len(cart.Items) == 0 {
+		common.RespondWithError(c, http.StatusBadRequest, "Cart is empty", nil)
+		return
+	}
+
+	pricing, err := common.PriceCartItems(cart.Items, shippingAddr)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Failed to price cart items", err)
+		return
+	}
+	if pricing.Delivery == nil {
+		common.RespondWithError(c, http.StatusUnprocessableEntity, "We can't deliver to this shipping address", errors.New(pricing.DeliveryUnavailable))
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Cart updated successfully", "status_code": http.StatusOK})
+	c.JSON(http.StatusOK, gin.H{"quote": pricing, "status_code": http.StatusOK})
 }
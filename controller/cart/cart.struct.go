@@ -1,21 +1,31 @@
 package cartController
 
+import (
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RequestUpdateCart defines the expected structure of the cart update body.
+// Totals are computed on the server; client values are optional and only
+// checked against the computed breakdown. Orders are placed via POST /checkout.
 type RequestUpdateCart struct {
 	ID          string             `json:"id"`
-	SubTotal    float64            `json:"sub_total" binding:"required"`
-	Total       float64            `json:"total" binding:"required"`
+	SubTotal    *models.Money      `json:"sub_total"`
+	Total       *models.Money      `json:"total"`
 	Items       []RequestItemsCart `json:"items" binding:"required"`
-	DeliveryFee float64            `json:"delivery_fee" binding:"required"`
-	Status      string             `json:"status"`
+	DeliveryFee *models.Money      `json:"delivery_fee"`
+	Tax         *models.Money      `json:"tax"`
 }
 
 type RequestItemsCart struct {
-	ProductID string  `json:"product_id"`
-	Qty       int     `json:"qty"`
-	Total     float64 `json:"total"`
+	ProductID string        `json:"product_id"`
+	VariantID string        `json:"variant_id"` // Required for products with variants
+	Qty       int           `json:"qty"`
+	Total     *models.Money `json:"total"`
 }
 
 type RequestBuildMatchStage struct {
-	CardID string `json:"id"`
-	Search string `json:"search"`
+	CardID string             `json:"id"`
+	UserID primitive.ObjectID `json:"user_id"` // Restricts to one user's carts when set
+	Search string             `json:"search"`
 }
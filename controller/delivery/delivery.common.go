@@ -1,26 +1,54 @@
 package deliveryController
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
-	"math/rand"
+	"crypto/rand"
+	"math/big"
 
 	"github.com/wachirawittd123/shop-online-backend-golang/common"
 	models "github.com/wachirawittd123/shop-online-backend-golang/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// generateRandomCode generates a random alphanumeric string of the specified length.
-func generateRandomCode(length int) string {
-	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
-	seededRand := rand.New(rand.NewSource(time.Now().UnixNano()))
+var (
+	// ErrDeliveryNotFound is returned when the delivery does not exist
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	// ErrIllegalDeliveryTransition is returned when the requested status change is not allowed
+	ErrIllegalDeliveryTransition = errors.New("illegal delivery status transition")
+)
+
+// deliveryOrderStatus maps delivery statuses to the order status they imply
+var deliveryOrderStatus = map[string]string{
+	models.StatusShipped:   models.OrderStatusShipped,
+	models.StatusDelivered: models.OrderStatusDelivered,
+}
+
+// trackingCodeCharset avoids characters that are easily confused when printed
+const trackingCodeCharset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// trackingCodeLength is the length of generated tracking codes
+const trackingCodeLength = 12
+
+// generateRandomCode generates a random code of the specified length from a
+// cryptographically secure source
+func generateRandomCode(length int) (string, error) {
+	max := big.NewInt(int64(len(trackingCodeCharset)))
 	code := make([]byte, length)
 	for i := range code {
-		code[i] = charset[seededRand.Intn(len(charset))]
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = trackingCodeCharset[n.Int64()]
 	}
-	return string(code)
+	return string(code), nil
 }
 
 func insertDelivery(delivery RequestCreateDelivery) error {
@@ -37,25 +65,213 @@ func insertDelivery(delivery RequestCreateDelivery) error {
 		return fmt.Errorf("duplicate OrderID: a delivery with this OrderID already exists")
 	}
 
-	// Generate random tracking code
-	trackingCode := generateRandomCode(10)
+	now := primitive.NewDateTimeFromTime(time.Now())
+
+	// Retry with a new tracking code if it collides with the unique index
+	for attempt := 0; attempt < 5; attempt++ {
+		trackingCode, err := generateRandomCode(trackingCodeLength)
+		if err != nil {
+			return err
+		}
+
+		// Prepare the Delivery document
+		newDelivery := bson.M{
+			"_id":           primitive.NewObjectID(),
+			"order_id":      delivery.OrderID,
+			"user_id":       delivery.UserID,
+			"status":        models.StatusPending, // Initial status
+			"delivery_fee":  delivery.DeliveryFee,
+			"distance_km":   delivery.DistanceKm,
+			"expected_date": delivery.ExpectedAt,
+			"created_at":    now,
+			"updated_on":    now,
+			"tracking_code": trackingCode,
+			"status_history": []models.DeliveryStatusChange{
+				{To: models.StatusPending, ActorID: delivery.UserID, ActorRole: models.RoleUser, ChangedAt: now},
+			},
+		}
+
+		// Insert into MongoDB
+		_, err = collection.InsertOne(ctx, newDelivery)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("failed to generate a unique tracking code")
+}
+
+// findDelivery retrieves a delivery matching the filter
+func findDelivery(filter bson.M) (models.Delivery, error) {
+	collection, ctx := common.GetCollection("delivery")
+	defer ctx.Done()
+
+	var delivery models.Delivery
+	err := collection.FindOne(ctx, filter).Decode(&delivery)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return delivery, ErrDeliveryNotFound
+	}
+	return delivery, err
+}
+
+// changeDeliveryStatus moves a delivery to a new status if allowed, keeps the
+// linked order in step and appends the change to the status history
+func changeDeliveryStatus(deliveryID primitive.ObjectID, to string, actor common.Actor, note string) (models.Delivery, error) {
+	delivery, err := findDelivery(bson.M{"_id": deliveryID})
+	if err != nil {
+		return delivery, err
+	}
+
+	if !models.CanTransitionDelivery(delivery.Status, to) {
+		return delivery, fmt.Errorf("%w: %s to %s", ErrIllegalDeliveryTransition, delivery.Status, to)
+	}
+
+	change := models.DeliveryStatusChange{
+		From:      delivery.Status,
+		To:        to,
+		ActorID:   actor.ID,
+		ActorRole: actor.Role,
+		Note:      note,
+		ChangedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
 
-	// Prepare the Delivery document
-	newDelivery := bson.M{
-		"_id":           primitive.NewObjectID(),
-		"order_id":      delivery.OrderID,
-		"user_id":       delivery.UserID,
-		"status":        models.StatusPending, // Initial status
-		"delivery_fee":  delivery.DeliveryFee,
-		"created_at":    primitive.NewDateTimeFromTime(time.Now()),
-		"updated_on":    primitive.NewDateTimeFromTime(time.Now()),
-		"tracking_code": trackingCode,
+	set := bson.M{"status": to, "updated_on": change.ChangedAt}
+	if to == models.StatusDelivered {
+		set["delivered_date"] = change.ChangedAt
+		delivery.DeliveredDate = change.ChangedAt
 	}
 
-	// Insert into MongoDB
-	_, err = collection.InsertOne(ctx, newDelivery)
+	collection, ctx := common.GetCollection("delivery")
+	defer ctx.Done()
+
+	// Only apply the change if nobody moved the delivery in the meantime
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": deliveryID, "status": delivery.Status}, bson.M{
+		"$set":  set,
+		"$push": bson.M{"status_history": change},
+	})
 	if err != nil {
-		return err
+		return delivery, err
+	}
+	if result.ModifiedCount == 0 {
+		return delivery, fmt.Errorf("%w: delivery status changed concurrently", ErrIllegalDeliveryTransition)
+	}
+
+	// Only the request that moved the delivery moves the order, and the
+	// delivery goes back if the order can't follow
+	if err := syncOrderStatus(delivery.OrderID, to, actor, note); err != nil {
+		revert := bson.M{
+			"$set":  bson.M{"status": delivery.Status, "updated_on": delivery.UpdatedOn},
+			"$pull": bson.M{"status_history": bson.M{"to": to, "changed_at": change.ChangedAt}},
+		}
+		if to == models.StatusDelivered {
+			revert["$unset"] = bson.M{"delivered_date": ""}
+		}
+		if _, revertErr := collection.UpdateOne(ctx, bson.M{"_id": deliveryID, "status": to}, revert); revertErr != nil {
+			log.Printf("Failed to revert delivery %s to %s after the order update failed: %v", deliveryID.Hex(), delivery.Status, revertErr)
+		}
+		return delivery, err
+	}
+
+	delivery.Status = to
+	delivery.UpdatedOn = change.ChangedAt
+	delivery.History = append(delivery.History, change)
+	return delivery, nil
+}
+
+// syncOrderStatus advances the order linked to a delivery when the delivery
+// status implies a new order status
+func syncOrderStatus(orderID primitive.ObjectID, deliveryStatus string, actor common.Actor, note string) error {
+	orderStatus, ok := deliveryOrderStatus[deliveryStatus]
+	if !ok {
+		return nil
+	}
+
+	order, err := common.TransitionOrderStatus(orderID, orderStatus, actor, note)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, common.ErrOrderNotFound):
+		// Deliveries created before orders existed reference carts
+		return nil
+	case errors.Is(err, common.ErrIllegalOrderTransition) && order.Status == orderStatus:
+		// A retried delivery may reach a status the order already has
+		return nil
+	}
+	return err
+}
+
+// buildDeliveryFilter builds the query filter for listing deliveries
+func buildDeliveryFilter(args RequestBuildDeliveryFilter) (bson.M, error) {
+	filter := bson.M{}
+
+	ids := map[string]string{
+		"user_id":            args.UserID,
+		"order_id":           args.OrderID,
+		"delivery_person_id": args.DeliveryPersonID,
+	}
+	for key, value := range ids {
+		if value == "" {
+			continue
+		}
+		objectID, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s format", key)
+		}
+		filter[key] = objectID
+	}
+
+	if args.Status != "" {
+		filter["status"] = args.Status
+	}
+
+	dateFilter := bson.M{}
+	if args.StartDate != "" {
+		start, err := time.Parse("2006-01-02", args.StartDate) // Format: YYYY-MM-DD
+		if err == nil {
+			dateFilter["$gte"] = primitive.NewDateTimeFromTime(start)
+		}
+	}
+	if args.EndDate != "" {
+		end, err := time.Parse("2006-01-02", args.EndDate) // Format: YYYY-MM-DD
+		if err == nil {
+			dateFilter["$lte"] = primitive.NewDateTimeFromTime(end)
+		}
+	}
+	if len(dateFilter) > 0 {
+		filter["created_at"] = dateFilter
+	}
+
+	return filter, nil
+}
+
+// listDeliveries fetches the deliveries matching the filter, newest first
+func listDeliveries(filter bson.M) ([]models.Delivery, error) {
+	collection, ctx := common.GetCollection("delivery")
+	defer ctx.Done()
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var deliveries []models.Delivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+
+	if deliveries == nil {
+		deliveries = []models.Delivery{}
+	}
+	return deliveries, nil
+}
+
+// buildTrackingTimeline strips actors and notes from the status history so
+// the public timeline exposes no personal data
+func buildTrackingTimeline(history []models.DeliveryStatusChange) []TrackingEvent {
+	timeline := make([]TrackingEvent, 0, len(history))
+	for _, change := range history {
+		timeline = append(timeline, TrackingEvent{Status: change.To, ChangedAt: change.ChangedAt.Time()})
 	}
-	return nil
+	return timeline
 }
@@ -1,5 +1,18 @@
 package deliveryController
 
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
 func AddDelivery(requestBody RequestCreateDelivery) int {
 
 	if err := insertDelivery(requestBody); err != nil {
@@ -8,3 +21,208 @@ func AddDelivery(requestBody RequestCreateDelivery) int {
 
 	return 200
 }
+
+// GetDeliveries lists all deliveries with optional filters
+func GetDeliveries(c *gin.Context) {
+	filter, err := buildDeliveryFilter(RequestBuildDeliveryFilter{
+		UserID:           c.Query("user_id"),
+		OrderID:          c.Query("order_id"),
+		DeliveryPersonID: c.Query("delivery_person_id"),
+		Status:           c.Query("status"),
+		StartDate:        c.Query("startDate"),
+		EndDate:          c.Query("endDate"),
+	})
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	deliveries, err := listDeliveries(filter)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch deliveries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries, "status_code": http.StatusOK})
+}
+
+// GetMyDeliveries lists the deliveries of the authenticated user
+func GetMyDeliveries(c *gin.Context) {
+	userID, err := common.GetUserIDFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	filter, err := buildDeliveryFilter(RequestBuildDeliveryFilter{Status: c.Query("status")})
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	filter["user_id"] = userID
+
+	deliveries, err := listDeliveries(filter)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch deliveries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries, "status_code": http.StatusOK})
+}
+
+// GetDelivery retrieves a single delivery; users can only view their own
+func GetDelivery(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	actor, err := common.ActorFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	filter := bson.M{"_id": objectID}
+	if !common.HasPermission(c, models.PermDeliveryRead) {
+		filter["user_id"] = actor.ID
+	}
+
+	delivery, err := findDelivery(filter)
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "Delivery not found", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"delivery": delivery, "status_code": http.StatusOK})
+}
+
+// AssignDelivery assigns a delivery person to a delivery
+func AssignDelivery(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	var requestBody RequestAssignDelivery
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	deliveryPersonID, err := primitive.ObjectIDFromHex(requestBody.DeliveryPersonID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid delivery person ID format", err)
+		return
+	}
+
+	users, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	count, err := users.CountDocuments(ctx, bson.M{"_id": deliveryPersonID, "role": models.RoleCourier})
+	if err != nil || count == 0 {
+		common.RespondWithError(c, http.StatusNotFound, "Courier not found", err)
+		return
+	}
+
+	delivery, err := findDelivery(bson.M{"_id": objectID})
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "Delivery not found", err)
+		return
+	}
+	if delivery.Status == models.StatusDelivered || delivery.Status == models.StatusCancelled {
+		common.RespondWithError(c, http.StatusConflict, "Delivery is already closed", nil)
+		return
+	}
+
+	update := bson.M{"$set": bson.M{
+		"delivery_person_id": deliveryPersonID,
+		"assignment_status":  models.AssignmentAssigned,
+		"assignment_note":    "",
+		"updated_on":         primitive.NewDateTimeFromTime(time.Now()),
+	}}
+
+	if err := common.UpdateOneCommonInDB(objectID, update, c, "delivery"); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery assigned successfully", "status_code": http.StatusOK})
+}
+
+// UpdateDeliveryStatus moves a delivery through its allowed statuses
+func UpdateDeliveryStatus(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	var requestBody RequestUpdateDeliveryStatus
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if !models.IsValidDeliveryStatus(requestBody.Status) {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid delivery status", nil)
+		return
+	}
+
+	actor, err := common.ActorFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	delivery, err := changeDeliveryStatus(objectID, requestBody.Status, actor, requestBody.Note)
+	if err != nil {
+		respondWithStatusError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery status updated successfully", "delivery": delivery, "status_code": http.StatusOK})
+}
+
+// TrackDelivery returns the public status and timeline of a delivery by tracking code
+func TrackDelivery(c *gin.Context) {
+	code := strings.TrimSpace(c.Param("code"))
+	if code == "" {
+		common.RespondWithError(c, http.StatusBadRequest, "Tracking code is required", nil)
+		return
+	}
+
+	// Generated codes are upper case; older codes are matched as given
+	delivery, err := findDelivery(bson.M{"tracking_code": bson.M{"$in": []string{code, strings.ToUpper(code)}}})
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "Tracking code not found", nil)
+		return
+	}
+
+	response := TrackingResponse{
+		TrackingCode: delivery.TrackingCode,
+		Status:       delivery.Status,
+		CreatedAt:    delivery.CreatedAt.Time(),
+		Timeline:     buildTrackingTimeline(delivery.History),
+	}
+	if delivery.ExpectedDate != 0 {
+		expectedDate := delivery.ExpectedDate.Time()
+		response.ExpectedDate = &expectedDate
+	}
+	if delivery.DeliveredDate != 0 {
+		deliveredDate := delivery.DeliveredDate.Time()
+		response.DeliveredDate = &deliveredDate
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tracking": response, "status_code": http.StatusOK})
+}
+
+// respondWithStatusError maps a status change error to its HTTP response
+func respondWithStatusError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrDeliveryNotFound):
+		common.RespondWithError(c, http.StatusNotFound, "Delivery not found", err)
+	case errors.Is(err, ErrIllegalDeliveryTransition), errors.Is(err, common.ErrIllegalOrderTransition):
+		common.RespondWithError(c, http.StatusConflict, "Delivery status change not allowed", err)
+	default:
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to update delivery status", err)
+	}
+}
@@ -0,0 +1,169 @@
+package deliveryController
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetCourierDeliveries lists the deliveries assigned to the authenticated courier
+func GetCourierDeliveries(c *gin.Context) {
+	courierID, err := common.GetUserIDFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	filter, err := buildDeliveryFilter(RequestBuildDeliveryFilter{Status: c.Query("status")})
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	filter["delivery_person_id"] = courierID
+
+	deliveries, err := listDeliveries(filter)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch deliveries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries, "status_code": http.StatusOK})
+}
+
+// AcceptDelivery lets a courier accept an assigned delivery and start it
+func AcceptDelivery(c *gin.Context) {
+	actor, delivery, ok := findCourierDelivery(c, models.AssignmentAssigned)
+	if !ok {
+		return
+	}
+
+	if delivery.Status == models.StatusPending || delivery.Status == models.StatusFailed {
+		if _, err := changeDeliveryStatus(delivery.ID, models.StatusInProgress, actor, "Accepted by courier"); err != nil {
+			respondWithStatusError(c, err)
+			return
+		}
+	}
+
+	update := bson.M{"$set": bson.M{
+		"assignment_status": models.AssignmentAccepted,
+		"updated_on":        primitive.NewDateTimeFromTime(time.Now()),
+	}}
+	if err := common.UpdateOneCommonInDB(delivery.ID, update, c, "delivery"); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery accepted successfully", "status_code": http.StatusOK})
+}
+
+// RejectDelivery lets a courier hand an assigned delivery back for reassignment
+func RejectDelivery(c *gin.Context) {
+	requestBody, err := bindCourierNote(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	_, delivery, ok := findCourierDelivery(c, models.AssignmentAssigned)
+	if !ok {
+		return
+	}
+
+	update := bson.M{"$set": bson.M{
+		"delivery_person_id": primitive.NilObjectID,
+		"assignment_status":  models.AssignmentRejected,
+		"assignment_note":    requestBody.Note,
+		"updated_on":         primitive.NewDateTimeFromTime(time.Now()),
+	}}
+	if err := common.UpdateOneCommonInDB(delivery.ID, update, c, "delivery"); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery rejected successfully", "status_code": http.StatusOK})
+}
+
+// MarkDeliveryPickedUp marks an accepted delivery as picked up and on its way
+func MarkDeliveryPickedUp(c *gin.Context) {
+	updateCourierDeliveryStatus(c, models.StatusShipped, false)
+}
+
+// MarkDeliveryDelivered marks an accepted delivery as delivered
+func MarkDeliveryDelivered(c *gin.Context) {
+	updateCourierDeliveryStatus(c, models.StatusDelivered, false)
+}
+
+// MarkDeliveryFailed marks an accepted delivery as failed; a note is required
+func MarkDeliveryFailed(c *gin.Context) {
+	updateCourierDeliveryStatus(c, models.StatusFailed, true)
+}
+
+// updateCourierDeliveryStatus applies a status change made by the assigned courier
+func updateCourierDeliveryStatus(c *gin.Context, status string, noteRequired bool) {
+	requestBody, err := bindCourierNote(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if noteRequired && requestBody.Note == "" {
+		common.RespondWithError(c, http.StatusBadRequest, "A note is required", nil)
+		return
+	}
+
+	actor, delivery, ok := findCourierDelivery(c, models.AssignmentAccepted)
+	if !ok {
+		return
+	}
+
+	delivery, err = changeDeliveryStatus(delivery.ID, status, actor, requestBody.Note)
+	if err != nil {
+		respondWithStatusError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery status updated successfully", "delivery": delivery, "status_code": http.StatusOK})
+}
+
+// findCourierDelivery loads the delivery from the route, checking that it is
+// assigned to the calling courier with the expected assignment status
+func findCourierDelivery(c *gin.Context, assignmentStatus string) (common.Actor, models.Delivery, bool) {
+	var delivery models.Delivery
+
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return common.Actor{}, delivery, false
+	}
+
+	actor, err := common.ActorFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return actor, delivery, false
+	}
+
+	delivery, err = findDelivery(bson.M{"_id": objectID, "delivery_person_id": actor.ID})
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "Delivery not found", err)
+		return actor, delivery, false
+	}
+
+	if delivery.AssignmentStatus != assignmentStatus {
+		common.RespondWithError(c, http.StatusConflict, "Delivery assignment is not "+assignmentStatus, nil)
+		return actor, delivery, false
+	}
+
+	return actor, delivery, true
+}
+
+// bindCourierNote binds the optional note body of courier actions
+func bindCourierNote(c *gin.Context) (RequestCourierNote, error) {
+	var requestBody RequestCourierNote
+	if err := c.ShouldBindJSON(&requestBody); err != nil && !errors.Is(err, io.EOF) {
+		return requestBody, err
+	}
+	return requestBody, nil
+}
@@ -1,9 +1,53 @@
 package deliveryController
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type RequestCreateDelivery struct {
-	OrderID     primitive.ObjectID `json:"order_id"`     // Reference to the cart/order ID
+	OrderID     primitive.ObjectID `json:"order_id"`     // Reference to the order ID
 	UserID      primitive.ObjectID `json:"user_id"`      // Reference to the user
-	DeliveryFee float64            `json:"delivery_fee"` // Cost of delivery
+	DeliveryFee models.Money       `json:"delivery_fee"` // Cost of delivery
+	DistanceKm  float64            `json:"distance_km"`  // Distance from the store to the shipping address
+	ExpectedAt  primitive.DateTime `json:"expected_at"`  // Expected delivery date
+}
+
+type RequestBuildDeliveryFilter struct {
+	UserID           string `json:"user_id"`
+	OrderID          string `json:"order_id"`
+	DeliveryPersonID string `json:"delivery_person_id"`
+	Status           string `json:"status"`
+	StartDate        string `json:"start_date"`
+	EndDate          string `json:"end_date"`
+}
+
+type RequestAssignDelivery struct {
+	DeliveryPersonID string `json:"delivery_person_id" binding:"required"`
+}
+
+type RequestCourierNote struct {
+	Note string `json:"note"`
+}
+
+type RequestUpdateDeliveryStatus struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note"`
+}
+
+// TrackingResponse is the public view of a delivery, free of personal data
+type TrackingResponse struct {
+	TrackingCode  string          `json:"tracking_code"`
+	Status        string          `json:"status"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ExpectedDate  *time.Time      `json:"expected_date,omitempty"`
+	DeliveredDate *time.Time      `json:"delivered_date,omitempty"`
+	Timeline      []TrackingEvent `json:"timeline"`
+}
+
+type TrackingEvent struct {
+	Status    string    `json:"status"`
+	ChangedAt time.Time `json:"changed_at"`
 }
@@ -0,0 +1,71 @@
+package deliveryZoneController
+
+import (
+	"fmt"
+
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// validateZone checks the polygon and fee values of a delivery zone, filling
+// in the currency of fees sent without one
+func validateZone(request *RequestDeliveryZone) error {
+	if request.Area.Type != "Polygon" {
+		return fmt.Errorf("area must be a GeoJSON Polygon")
+	}
+	if len(request.Area.Coordinates) == 0 {
+		return fmt.Errorf("area must have at least one ring")
+	}
+	for _, ring := range request.Area.Coordinates {
+		if len(ring) < 4 {
+			return fmt.Errorf("each ring needs at least four positions")
+		}
+		for _, position := range ring {
+			if len(position) != 2 {
+				return fmt.Errorf("positions must be [longitude, latitude] pairs")
+			}
+		}
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return fmt.Errorf("each ring must be closed")
+		}
+	}
+	if request.LeadTimeDays < 0 {
+		return fmt.Errorf("lead time must not be negative")
+	}
+	if err := common.CheckStoreAmount(&request.BaseFee); err != nil {
+		return err
+	}
+	if err := common.CheckStoreAmount(&request.FreeShippingThreshold); err != nil {
+		return err
+	}
+	for i := range request.FeeTiers {
+		if err := common.CheckStoreAmount(&request.FeeTiers[i].PerKm); err != nil {
+			return err
+		}
+	}
+	return common.ValidateFeeTiers(request.FeeTiers)
+}
+
+// zoneFields maps a request to the stored zone fields
+func zoneFields(request RequestDeliveryZone) bson.M {
+	feeTiers := request.FeeTiers
+	if feeTiers == nil {
+		feeTiers = []models.FeeTier{}
+	}
+	active := true
+	if request.Active != nil {
+		active = *request.Active
+	}
+
+	return bson.M{
+		"name":                    request.Name,
+		"area":                    request.Area,
+		"base_fee":                request.BaseFee,
+		"fee_tiers":               feeTiers,
+		"free_shipping_threshold": request.FreeShippingThreshold,
+		"lead_time_days":          request.LeadTimeDays,
+		"active":                  active,
+	}
+}
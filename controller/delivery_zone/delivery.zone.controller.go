@@ -0,0 +1,106 @@
+package deliveryZoneController
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func GetDeliveryZones(c *gin.Context) {
+	collection, ctx := common.GetCollection("delivery_zones")
+	defer ctx.Done()
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch delivery zones", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var zones []models.DeliveryZone
+	if err := cursor.All(ctx, &zones); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to decode delivery zones", err)
+		return
+	}
+
+	if zones == nil {
+		zones = []models.DeliveryZone{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"delivery_zones": zones, "status_code": http.StatusOK})
+}
+
+func AddDeliveryZone(c *gin.Context) {
+	var requestBody RequestDeliveryZone
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := validateZone(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid delivery zone", err)
+		return
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	zone := zoneFields(requestBody)
+	zone["_id"] = primitive.NewObjectID()
+	zone["created_at"] = now
+	zone["updated_on"] = now
+
+	collection, ctx := common.GetCollection("delivery_zones")
+	defer ctx.Done()
+
+	// MongoDB rejects polygons the 2dsphere index can't use
+	if _, err := collection.InsertOne(ctx, zone); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Failed to add delivery zone", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery zone added successfully", "id": zone["_id"], "status_code": http.StatusOK})
+}
+
+func UpdateDeliveryZone(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	var requestBody RequestDeliveryZone
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := validateZone(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid delivery zone", err)
+		return
+	}
+
+	fields := zoneFields(requestBody)
+	fields["updated_on"] = primitive.NewDateTimeFromTime(time.Now())
+
+	if err := common.UpdateOneCommonInDB(objectID, bson.M{"$set": fields}, c, "delivery_zones"); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery zone updated successfully", "status_code": http.StatusOK})
+}
+
+func RemoveDeliveryZone(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	if err := common.DeleteOneCommonByID(objectID, c, "delivery_zones"); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery zone deleted successfully", "status_code": http.StatusOK})
+}
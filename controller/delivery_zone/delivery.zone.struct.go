@@ -0,0 +1,16 @@
+package deliveryZoneController
+
+import (
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+)
+
+// RequestDeliveryZone defines the expected structure of a delivery zone body
+type RequestDeliveryZone struct {
+	Name                  string            `json:"name" binding:"required"`
+	Area                  models.GeoPolygon `json:"area" binding:"required"`
+	BaseFee               models.Money      `json:"base_fee"`
+	FeeTiers              []models.FeeTier  `json:"fee_tiers"`
+	FreeShippingThreshold models.Money      `json:"free_shipping_threshold"`
+	LeadTimeDays          int               `json:"lead_time_days"`
+	Active                *bool             `json:"active"`
+}
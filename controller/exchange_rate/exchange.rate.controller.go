@@ -0,0 +1,134 @@
+package exchangeRateController
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxImportSize limits the size of an uploaded exchange rate file
+const maxImportSize = 1 << 20
+
+// GetExchangeRates lists exchange rates, newest first per currency
+func GetExchangeRates(c *gin.Context) {
+	filter := bson.M{}
+	if currency := c.Query("currency"); currency != "" {
+		filter["currency"] = strings.ToUpper(currency)
+	}
+
+	collection, ctx := common.GetCollection("exchange_rates")
+	defer ctx.Done()
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "currency", Value: 1}, {Key: "effective_from", Value: -1}}))
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch exchange rates", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var rates []models.ExchangeRate
+	if err := cursor.All(ctx, &rates); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to decode exchange rates", err)
+		return
+	}
+
+	if rates == nil {
+		rates = []models.ExchangeRate{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exchange_rates": rates, "store_currency": common.AppConfig.StoreCurrency, "status_code": http.StatusOK})
+}
+
+// AddExchangeRate adds a rate, replacing one of the same currency and effective date
+func AddExchangeRate(c *gin.Context) {
+	var requestBody RequestAddExchangeRate
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	effectiveFrom := time.Now()
+	if requestBody.EffectiveFrom != "" {
+		parsed, err := common.ParseEffectiveDate(requestBody.EffectiveFrom)
+		if err != nil {
+			common.RespondWithError(c, http.StatusBadRequest, "Invalid effective_from", err)
+			return
+		}
+		effectiveFrom = parsed
+	}
+
+	actor, err := common.ActorFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	rate, err := common.NewExchangeRate(requestBody.Currency, requestBody.Rate.String(), effectiveFrom, actor.ID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid exchange rate", err)
+		return
+	}
+
+	if err := common.SaveExchangeRates([]models.ExchangeRate{rate}); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to add exchange rate", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rate added successfully", "exchange_rate": rate, "status_code": http.StatusOK})
+}
+
+// ImportExchangeRates loads rates from a CSV file with currency, rate and
+// effective_from columns, sent as the "file" form field or as the request
+// body. Nothing is stored unless every row is valid.
+func ImportExchangeRates(c *gin.Context) {
+	var source io.Reader = c.Request.Body
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			common.RespondWithError(c, http.StatusBadRequest, "Failed to read uploaded file", err)
+			return
+		}
+		defer file.Close()
+		source = file
+	}
+
+	actor, err := common.ActorFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	rates, err := common.ParseExchangeRatesCSV(io.LimitReader(source, maxImportSize), actor.ID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid exchange rate file", err)
+		return
+	}
+
+	if err := common.SaveExchangeRates(rates); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to import exchange rates", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rates imported successfully", "imported": len(rates), "status_code": http.StatusOK})
+}
+
+// RemoveExchangeRate deletes an exchange rate
+func RemoveExchangeRate(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	if err := common.DeleteOneCommonByID(objectID, c, "exchange_rates"); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted successfully", "status_code": http.StatusOK})
+}
@@ -0,0 +1,10 @@
+package exchangeRateController
+
+import "encoding/json"
+
+// RequestAddExchangeRate defines the expected structure of an exchange rate body
+type RequestAddExchangeRate struct {
+	Currency      string      `json:"currency" binding:"required"`
+	Rate          json.Number `json:"rate" binding:"required"` // Units of currency per unit of the store currency
+	EffectiveFrom string      `json:"effective_from"`          // Date or RFC 3339 timestamp, defaults to now
+}
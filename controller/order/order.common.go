@@ -0,0 +1,167 @@
+package orderController
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// findActiveCart retrieves the user's active cart
+func findActiveCart(userID primitive.ObjectID) (models.Cart, error) {
+	collection, ctx := common.GetCollection("carts")
+	defer ctx.Done()
+
+	var cart models.Cart
+	err := collection.FindOne(ctx, bson.M{"user_id": userID, "status": models.CartStatusActive}).Decode(&cart)
+	return cart, err
+}
+
+// findUser retrieves a user by ID
+func findUser(userID primitive.ObjectID) (models.User, error) {
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	return user, err
+}
+
+// buildOrder snapshots the priced cart and shipping address into a new order
+func buildOrder(cart models.Cart, user models.User, pricing common.PriceBreakdown, rate models.ExchangeRate, actor common.Actor) (models.Order, error) {
+	displayTotal, err := common.ConvertMoney(pricing.Total, rate)
+	if err != nil {
+		return models.Order{}, err
+	}
+	now := primitive.NewDateTimeFromTime(time.Now())
+
+	items := make([]models.OrderItem, 0, len(pricing.Items))
+	for _, item := range pricing.Items {
+		product := pricing.Products[item.ProductID]
+		variant, _ := product.FindVariant(item.VariantID)
+		unitPrice, _ := common.UnitPrice(product, item.VariantID)
+		items = append(items, models.OrderItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Name:      product.Name,
+			SKU:       variant.SKU,
+			Options:   variant.Options,
+			UnitPrice: unitPrice,
+			Qty:       item.Qty,
+			Total:     item.Total,
+			TaxClass:  item.TaxClass,
+			TaxRate:   item.TaxRate,
+			Net:       item.Net,
+			Tax:       item.Tax,
+		})
+	}
+
+	return models.Order{
+		ID:           primitive.NewObjectID(),
+		UserID:       user.ID,
+		CartID:       cart.ID,
+		Items:        items,
+		ShippingAddr: user.ShippingAddr,
+		Status:       models.OrderStatusPendingPayment,
+		SubTotal:     pricing.SubTotal,
+		DeliveryFee:  pricing.DeliveryFee,
+		DistanceKm:   pricing.Delivery.DistanceKm,
+		ZoneID:       pricing.Delivery.ZoneID,
+		ExpectedDeliveryDate: primitive.NewDateTimeFromTime(
+			time.Now().AddDate(0, 0, pricing.Delivery.LeadTimeDays),
+		),
+		Tax:              pricing.Tax,
+		DeliveryTax:      pricing.DeliveryTax,
+		TaxLines:         pricing.TaxLines,
+		PricesIncludeTax: pricing.PricesIncludeTax,
+		Total:            pricing.Total,
+		DisplayCurrency:  rate.Currency,
+		ExchangeRate:     rate.Rate,
+		ExchangeRateID:   rate.ID,
+		DisplayTotal:     displayTotal,
+		History: []models.OrderStatusChange{
+			common.NewOrderStatusChange("", models.OrderStatusPendingPayment, actor, "Order placed"),
+		},
+		CreatedAt: now,
+		UpdatedOn: now,
+	}, nil
+}
+
+// setCartStatus moves a cart from one status to another, failing if the
+// cart is no longer in the expected status (e.g. a concurrent checkout)
+func setCartStatus(cartID primitive.ObjectID, from string, to string) error {
+	collection, ctx := common.GetCollection("carts")
+	defer ctx.Done()
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": cartID, "status": from}, bson.M{"$set": bson.M{
+		"status":     to,
+		"updated_on": primitive.NewDateTimeFromTime(time.Now()),
+	}})
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return fmt.Errorf("cart is no longer %s", from)
+	}
+	return nil
+}
+
+// insertOrder inserts an order into the database
+func insertOrder(order models.Order) error {
+	collection, ctx := common.GetCollection("orders")
+	defer ctx.Done()
+
+	_, err := collection.InsertOne(ctx, order)
+	return err
+}
+
+// rollbackCheckout undoes the steps a failed checkout got through: it
+// deletes the inserted order, releases reserved stock and reopens the cart.
+// Whatever can't be undone is logged so an operator can fix it by hand.
+func rollbackCheckout(order models.Order, orderInserted bool, reservedStock []common.StockLine) {
+	if orderInserted {
+		if err := deleteOrder(order.ID); err != nil {
+			log.Printf("Checkout rollback: failed to delete order %s of cart %s: %v", order.ID.Hex(), order.CartID.Hex(), err)
+		}
+	}
+	if len(reservedStock) > 0 {
+		if err := common.ReleaseStock(order.ID, reservedStock); err != nil {
+			log.Printf("Checkout rollback: failed to release the stock of order %s of cart %s: %v", order.ID.Hex(), order.CartID.Hex(), err)
+		}
+	}
+	if err := setCartStatus(order.CartID, models.CartStatusCompleted, models.CartStatusActive); err != nil {
+		log.Printf("Checkout rollback: failed to reopen cart %s of order %s: %v", order.CartID.Hex(), order.ID.Hex(), err)
+	}
+}
+
+// deleteOrder removes an order that couldn't be completed
+func deleteOrder(orderID primitive.ObjectID) error {
+	collection, ctx := common.GetCollection("orders")
+	defer ctx.Done()
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": orderID})
+	return err
+}
+
+// buildOrderFilter builds the query filter for listing orders
+func buildOrderFilter(args RequestBuildOrderFilter) (bson.M, error) {
+	filter := bson.M{}
+
+	if args.UserID != "" {
+		objectUserID, err := primitive.ObjectIDFromHex(args.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user ID format")
+		}
+		filter["user_id"] = objectUserID
+	}
+
+	if args.Status != "" {
+		filter["status"] = args.Status
+	}
+
+	return filter, nil
+}
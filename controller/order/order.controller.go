@@ -0,0 +1,238 @@
+package orderController
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	deliveryController "github.com/wachirawittd123/shop-online-backend-golang/controller/delivery"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Checkout turns the user's active cart into an order and closes the cart
+func Checkout(c *gin.Context) {
+	actor, err := common.ActorFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+	userID := actor.ID
+
+	// Record the currency the customer paid attention to; the order is
+	// charged in the store currency either way
+	rate, ok := common.ExchangeRateFromQuery(c)
+	if !ok {
+		return
+	}
+	if rate == nil {
+		rate = &models.ExchangeRate{Currency: common.AppConfig.StoreCurrency, Rate: "1"}
+	}
+
+	cart, err := findActiveCart(userID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "No active cart to check out", err)
+		return
+	}
+	if len(cart.Items) == 0 {
+		common.RespondWithError(c, http.StatusBadRequest, "Cart is empty", nil)
+		return
+	}
+
+	user, err := findUser(userID)
+	if err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if user.ShippingAddr.Street == "" {
+		common.RespondWithError(c, http.StatusBadRequest, "Shipping address is required", nil)
+		return
+	}
+
+	// Re-price the cart so the order reflects current catalog prices
+	pricing, err := common.PriceCartItems(cart.Items, user.ShippingAddr)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Failed to price cart items", err)
+		return
+	}
+	if pricing.Delivery == nil {
+		common.RespondWithError(c, http.StatusUnprocessableEntity, "We can't deliver to this shipping address", errors.New(pricing.DeliveryUnavailable))
+		return
+	}
+
+	order, err := buildOrder(cart, user, pricing, *rate, actor)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to convert order total", err)
+		return
+	}
+
+	// Close the cart first so concurrent checkouts of the same cart fail
+	if err := setCartStatus(cart.ID, models.CartStatusActive, models.CartStatusCompleted); err != nil {
+		common.RespondWithError(c, http.StatusConflict, "Cart has already been checked out", err)
+		return
+	}
+
+	// Reserve stock before the order exists so we never oversell
+	stockLines := common.StockLinesFromOrderItems(order.Items)
+	if err := common.ReserveStock(order.ID, stockLines); err != nil {
+		rollbackCheckout(order, false, nil)
+		if errors.Is(err, common.ErrInsufficientStock) {
+			common.RespondWithError(c, http.StatusConflict, "Not enough stock to place order", err)
+			return
+		}
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to reserve stock", err)
+		return
+	}
+
+	if err := insertOrder(order); err != nil {
+		rollbackCheckout(order, false, stockLines)
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to place order", err)
+		return
+	}
+
+	deliveryRequest := deliveryController.RequestCreateDelivery{
+		OrderID:     order.ID,
+		UserID:      userID,
+		DeliveryFee: order.DeliveryFee,
+		DistanceKm:  order.DistanceKm,
+		ExpectedAt:  order.ExpectedDeliveryDate,
+	}
+	if status := deliveryController.AddDelivery(deliveryRequest); status != http.StatusOK {
+		// Undo the checkout so the user can retry with the same cart
+		rollbackCheckout(order, true, stockLines)
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to add delivery", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order placed successfully", "order": order, "status_code": http.StatusOK})
+}
+
+// GetOrders lists orders; users only see their own orders
+func GetOrders(c *gin.Context) {
+	requestParams := RequestBuildOrderFilter{
+		UserID: c.Query("user_id"),
+		Status: c.Query("status"),
+	}
+
+	// Restrict callers without order:read to their own orders
+	if !common.HasPermission(c, models.PermOrderRead) {
+		userID, _ := c.Get("userID")
+		requestParams.UserID, _ = userID.(string)
+	}
+
+	filter, err := buildOrderFilter(requestParams)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	collection, ctx := common.GetCollection("orders")
+	defer ctx.Done()
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch orders", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var orders []models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to decode orders", err)
+		return
+	}
+
+	if orders == nil {
+		orders = []models.Order{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orders": orders, "status_code": http.StatusOK})
+}
+
+// GetOrder retrieves a single order; users can only view their own orders
+func GetOrder(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	filter := bson.M{"_id": objectID}
+	if !common.HasPermission(c, models.PermOrderRead) {
+		userID, err := common.GetUserIDFromContext(c)
+		if err != nil {
+			common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+			return
+		}
+		filter["user_id"] = userID
+	}
+
+	collection, ctx := common.GetCollection("orders")
+	defer ctx.Done()
+
+	var order models.Order
+	if err := collection.FindOne(ctx, filter).Decode(&order); err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "Order not found", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"order": order, "status_code": http.StatusOK})
+}
+
+// UpdateOrderStatus moves an order through its lifecycle. Callers with
+// order:update may make any allowed transition; others may only cancel their
+// own unpaid orders.
+func UpdateOrderStatus(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	var requestBody RequestUpdateOrderStatus
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if !models.IsValidOrderStatus(requestBody.Status) {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid order status", nil)
+		return
+	}
+
+	actor, err := common.ActorFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	if !common.HasPermission(c, models.PermOrderUpdate) {
+		collection, ctx := common.GetCollection("orders")
+		defer ctx.Done()
+
+		var order models.Order
+		if err := collection.FindOne(ctx, bson.M{"_id": objectID, "user_id": actor.ID}).Decode(&order); err != nil {
+			common.RespondWithError(c, http.StatusNotFound, "Order not found", err)
+			return
+		}
+		if requestBody.Status != models.OrderStatusCancelled || order.Status != models.OrderStatusPendingPayment {
+			common.RespondWithError(c, http.StatusForbidden, "Only unpaid orders can be cancelled", nil)
+			return
+		}
+	}
+
+	order, err := common.TransitionOrderStatus(objectID, requestBody.Status, actor, requestBody.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrOrderNotFound):
+			common.RespondWithError(c, http.StatusNotFound, "Order not found", err)
+		case errors.Is(err, common.ErrIllegalOrderTransition):
+			common.RespondWithError(c, http.StatusConflict, "Order status change not allowed", err)
+		default:
+			common.RespondWithError(c, http.StatusInternalServerError, "Failed to update order status", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully", "order": order, "status_code": http.StatusOK})
+}
@@ -0,0 +1,11 @@
+package orderController
+
+type RequestBuildOrderFilter struct {
+	UserID string `json:"user_id"`
+	Status string `json:"status"`
+}
+
+type RequestUpdateOrderStatus struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note"`
+}
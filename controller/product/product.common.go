@@ -2,7 +2,9 @@ package productController
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -28,6 +30,34 @@ func GetProductById(id string, collection *mongo.Collection, ctx context.Context
 	return existingProduct
 }
 
+// addDisplayPrices adds the price of a product and of each of its variants
+// converted with the exchange rate as display_price
+func addDisplayPrices(product bson.M, rate models.ExchangeRate) error {
+	price, ok, err := common.ConvertStoredAmount(product["price"], rate)
+	if err != nil {
+		return err
+	}
+	if ok {
+		product["display_price"] = price
+	}
+
+	variants, _ := product["variants"].(bson.A)
+	for _, element := range variants {
+		variant, ok := element.(bson.M)
+		if !ok {
+			continue
+		}
+		price, ok, err := common.ConvertStoredAmount(variant["price"], rate)
+		if err != nil {
+			return err
+		}
+		if ok {
+			variant["display_price"] = price
+		}
+	}
+	return nil
+}
+
 // isProductNameTaken checks if the product name is already in use
 func isProductNameTaken(name string, c *gin.Context) bool {
 	collection, ctx := common.GetCollection("products")
@@ -41,15 +71,166 @@ func isProductNameTaken(name string, c *gin.Context) bool {
 	return false
 }
 
+// isSKUTaken checks if a variant SKU is already used by another product
+func isSKUTaken(variants []models.ProductVariant, productID primitive.ObjectID, c *gin.Context) bool {
+	if len(variants) == 0 {
+		return false
+	}
+
+	skus := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		skus = append(skus, variant.SKU)
+	}
+
+	collection, ctx := common.GetCollection("products")
+	defer ctx.Done()
+
+	var existingProduct models.Product
+	err := collection.FindOne(ctx, bson.M{"variants.sku": bson.M{"$in": skus}, "_id": bson.M{"$ne": productID}}).Decode(&existingProduct)
+	if err == nil {
+		common.RespondWithError(c, http.StatusConflict, "SKU already in use by product "+existingProduct.Name, nil)
+		return true
+	}
+	return false
+}
+
+// buildVariants validates the requested option axes and variants and turns
+// them into product variants. Variants sent with the ID of one of the
+// existing variants keep their ID and stock; stock only changes through
+// stock adjustments and orders.
+func buildVariants(request AddProductRequest, existing models.Product) ([]models.ProductOption, []models.ProductVariant, error) {
+	if len(request.Variants) == 0 {
+		if len(request.Options) > 0 {
+			return nil, nil, fmt.Errorf("options require at least one variant")
+		}
+		return nil, nil, nil
+	}
+
+	allowed, err := validateOptions(request.Options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	variants := make([]models.ProductVariant, 0, len(request.Variants))
+	seenSKUs := map[string]bool{}
+	seenIDs := map[primitive.ObjectID]bool{}
+	seenCombinations := map[string]bool{}
+	for _, requested := range request.Variants {
+		sku := strings.TrimSpace(requested.SKU)
+		if sku == "" || seenSKUs[sku] {
+			return nil, nil, fmt.Errorf("SKU %q is empty or used twice", requested.SKU)
+		}
+		seenSKUs[sku] = true
+
+		if len(requested.Options) != len(request.Options) {
+			return nil, nil, fmt.Errorf("variant %s must choose exactly one value for every option", sku)
+		}
+		values := make([]string, 0, len(request.Options))
+		for _, option := range request.Options {
+			value, ok := requested.Options[option.Name]
+			if !ok || !allowed[option.Name][value] {
+				return nil, nil, fmt.Errorf("variant %s has an invalid value for option %s", sku, option.Name)
+			}
+			values = append(values, value)
+		}
+		combination := strings.Join(values, "\x00")
+		if seenCombinations[combination] {
+			return nil, nil, fmt.Errorf("variant %s repeats the options of another variant", sku)
+		}
+		seenCombinations[combination] = true
+
+		price := request.Price
+		if requested.Price != nil {
+			price = *requested.Price
+		}
+		if requested.Stock < 0 {
+			return nil, nil, fmt.Errorf("variant %s has negative stock", sku)
+		}
+
+		variant := models.ProductVariant{
+			ID:      primitive.NewObjectID(),
+			SKU:     sku,
+			Barcode: strings.TrimSpace(requested.Barcode),
+			Options: requested.Options,
+			Price:   price,
+			Stock:   requested.Stock,
+		}
+		if requested.ID != "" {
+			id, err := primitive.ObjectIDFromHex(requested.ID)
+			previous, ok := existing.FindVariant(id)
+			if err != nil || !ok || seenIDs[id] {
+				return nil, nil, fmt.Errorf("unknown variant ID: %s", requested.ID)
+			}
+			seenIDs[id] = true
+			variant.ID, variant.Stock = previous.ID, previous.Stock
+		}
+		variants = append(variants, variant)
+	}
+
+	return request.Options, variants, nil
+}
+
+// checkPrices checks that the product and variant prices are in the store
+// currency and not negative. A product must have a price; its variants
+// default to it.
+func checkPrices(request *AddProductRequest) error {
+	if err := common.CheckStoreAmount(&request.Price); err != nil {
+		return err
+	}
+	if request.Price.IsZero() {
+		return fmt.Errorf("price is required")
+	}
+	for _, variant := range request.Variants {
+		if variant.Price == nil {
+			continue
+		}
+		if err := common.CheckStoreAmount(variant.Price); err != nil {
+			return fmt.Errorf("variant %s: %v", variant.SKU, err)
+		}
+	}
+	return nil
+}
+
+// validateOptions checks that option axes have unique names and values and
+// returns the allowed values of each axis
+func validateOptions(options []models.ProductOption) (map[string]map[string]bool, error) {
+	if len(options) == 0 {
+		return nil, fmt.Errorf("variants require at least one option")
+	}
+
+	allowed := map[string]map[string]bool{}
+	for _, option := range options {
+		if option.Name == "" || allowed[option.Name] != nil || len(option.Values) == 0 {
+			return nil, fmt.Errorf("option %q must have a unique name and at least one value", option.Name)
+		}
+		values := map[string]bool{}
+		for _, value := range option.Values {
+			if value == "" || values[value] {
+				return nil, fmt.Errorf("option %s has an empty or repeated value", option.Name)
+			}
+			values[value] = true
+		}
+		allowed[option.Name] = values
+	}
+	return allowed, nil
+}
+
 // prepareProductForInsertion prepares a product document for insertion
-func prepareProductForInsertion(request AddProductRequest, idCategory primitive.ObjectID) models.Product {
+func prepareProductForInsertion(request AddProductRequest, idCategory primitive.ObjectID, options []models.ProductOption, variants []models.ProductVariant) models.Product {
 	now := primitive.NewDateTimeFromTime(time.Now())
+	stock := request.Stock
+	if len(variants) > 0 {
+		stock = 0
+	}
 	return models.Product{
 		ID:         primitive.NewObjectID(),
 		Name:       request.Name,
 		Price:      request.Price,
 		Detail:     request.Detail,
+		Stock:      stock,
 		IDCategory: idCategory,
+		Options:    options,
+		Variants:   variants,
 		CreatedAt:  now,
 		UpdatedOn:  now,
 	}
@@ -65,3 +246,17 @@ func insertProduct(product models.Product, c *gin.Context) error {
 	}
 	return err
 }
+
+// stockUnchangedFilter matches a product only while its stock, and that of
+// each of its variants, is still what was read
+func stockUnchangedFilter(product models.Product) bson.M {
+	conditions := bson.A{bson.M{"_id": product.ID}, bson.M{"stock": product.Stock}}
+	if product.Stock == 0 {
+		// Products created before stock was tracked have no stock field
+		conditions[1] = bson.M{"stock": bson.M{"$in": bson.A{0, nil}}}
+	}
+	for _, variant := range product.Variants {
+		conditions = append(conditions, bson.M{"variants": bson.M{"$elemMatch": bson.M{"_id": variant.ID, "stock": variant.Stock}}})
+	}
+	return bson.M{"$and": conditions}
+}
@@ -1,13 +1,16 @@
 package productController
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func GetProducts(c *gin.Context) {
@@ -15,6 +18,12 @@ func GetProducts(c *gin.Context) {
 	startDate := c.Query("startDate")
 	endDate := c.Query("endDate")
 
+	// Prices are also shown in the requested currency, if any
+	rate, ok := common.ExchangeRateFromQuery(c)
+	if !ok {
+		return
+	}
+
 	collection, ctx := common.GetCollection("products")
 
 	// Build filters for the aggregation pipeline
@@ -22,6 +31,8 @@ func GetProducts(c *gin.Context) {
 	if search != "" {
 		filter["$or"] = []bson.M{
 			{"name": bson.M{"$regex": search, "$options": "i"}},
+			{"variants.sku": search},
+			{"variants.barcode": search},
 		}
 	}
 
@@ -80,8 +91,19 @@ func GetProducts(c *gin.Context) {
 		results = []bson.M{}
 	}
 
+	response := gin.H{"products": results, "status_code": http.StatusOK}
+	if rate != nil {
+		for _, product := range results {
+			if err := addDisplayPrices(product, *rate); err != nil {
+				common.RespondWithError(c, http.StatusInternalServerError, "Failed to convert product prices", err)
+				return
+			}
+		}
+		response["exchange_rate"] = rate
+	}
+
 	// Respond with the joined data
-	c.JSON(http.StatusOK, gin.H{"products": results, "status_code": http.StatusOK})
+	c.JSON(http.StatusOK, response)
 }
 
 func GetProduct(c *gin.Context) {
@@ -113,8 +135,23 @@ func AddProduct(c *gin.Context) {
 		return
 	}
 
+	if err := checkPrices(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid product price", err)
+		return
+	}
+
+	// Validate the variants and check their SKUs are unused
+	options, variants, err := buildVariants(requestBody, models.Product{})
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid product variants", err)
+		return
+	}
+	if isSKUTaken(variants, primitive.NilObjectID, c) {
+		return
+	}
+
 	// Prepare the product for insertion
-	product := prepareProductForInsertion(requestBody, idCategory)
+	product := prepareProductForInsertion(requestBody, idCategory, options, variants)
 
 	// Insert the product into the database
 	if err := insertProduct(product, c); err != nil {
@@ -145,19 +182,54 @@ func UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	if err := checkPrices(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid product price", err)
+		return
+	}
+
+	collection, ctx := common.GetCollection("products")
+	defer ctx.Done()
+
+	var existingProduct models.Product
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&existingProduct); err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "Product not found", nil)
+		return
+	}
+
+	// Validate the variants, keeping the stock of the ones that remain
+	options, variants, err := buildVariants(requestBody, existingProduct)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid product variants", err)
+		return
+	}
+	if isSKUTaken(variants, objectID, c) {
+		return
+	}
+
 	// Prepare the update document
-	update := bson.M{
-		"$set": bson.M{
-			"name":        requestBody.Name,
-			"price":       requestBody.Price,
-			"detail":      requestBody.Detail,
-			"id_category": idCategory,
-			"updated_on":  primitive.NewDateTimeFromTime(time.Now()),
-		},
+	set := bson.M{
+		"name":        requestBody.Name,
+		"price":       requestBody.Price,
+		"detail":      requestBody.Detail,
+		"id_category": idCategory,
+		"options":     options,
+		"variants":    variants,
+		"updated_on":  primitive.NewDateTimeFromTime(time.Now()),
+	}
+	if len(variants) > 0 {
+		// Stock is kept per variant from now on
+		set["stock"] = 0
 	}
 
-	// Perform the update operation
-	if err := common.UpdateOneCommonInDB(objectID, update, c, "products"); err != nil {
+	// The variants are written back with the stock read above, so only
+	// write while no reservation or adjustment has changed it since
+	result, err := collection.UpdateOne(ctx, stockUnchangedFilter(existingProduct), bson.M{"$set": set})
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to update", err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		common.RespondWithError(c, http.StatusConflict, "Product stock changed during the update, please try again", nil)
 		return
 	}
 
@@ -178,3 +250,95 @@ func RemoveProduct(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully", "status_code": http.StatusOK})
 }
+
+// AdjustStock applies a manual stock change with a reason code
+func AdjustStock(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	var requestBody AdjustStockRequest
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if !models.IsValidStockAdjustmentReason(requestBody.Reason) {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid stock adjustment reason", nil)
+		return
+	}
+
+	actorID, err := common.GetUserIDFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	var variantID primitive.ObjectID
+	if requestBody.VariantID != "" {
+		if variantID, err = primitive.ObjectIDFromHex(requestBody.VariantID); err != nil {
+			common.RespondWithError(c, http.StatusBadRequest, "Invalid variant ID format", err)
+			return
+		}
+	}
+
+	stock, err := common.AdjustStock(objectID, variantID, requestBody.Delta, requestBody.Reason, actorID, requestBody.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrProductNotFound):
+			common.RespondWithError(c, http.StatusNotFound, "Product not found", err)
+		case errors.Is(err, common.ErrVariantNotFound):
+			common.RespondWithError(c, http.StatusNotFound, "Product variant not found", err)
+		case errors.Is(err, common.ErrVariantRequired):
+			common.RespondWithError(c, http.StatusBadRequest, "variant_id is required for products with variants", err)
+		case errors.Is(err, common.ErrInsufficientStock):
+			common.RespondWithError(c, http.StatusConflict, "Stock cannot go below zero", err)
+		default:
+			common.RespondWithError(c, http.StatusInternalServerError, "Failed to adjust stock", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Stock adjusted successfully", "stock": stock, "status_code": http.StatusOK})
+}
+
+// GetStockMovements lists the stock movement log of a product
+func GetStockMovements(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	collection, ctx := common.GetCollection("stock_movements")
+	defer ctx.Done()
+
+	filter := bson.M{"product_id": objectID}
+	if variantID := c.Query("variant_id"); variantID != "" {
+		objectVariantID, err := primitive.ObjectIDFromHex(variantID)
+		if err != nil {
+			common.RespondWithError(c, http.StatusBadRequest, "Invalid variant ID format", err)
+			return
+		}
+		filter["variant_id"] = objectVariantID
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch stock movements", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var movements []models.StockMovement
+	if err := cursor.All(ctx, &movements); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to decode stock movements", err)
+		return
+	}
+
+	if movements == nil {
+		movements = []models.StockMovement{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stock_movements": movements, "status_code": http.StatusOK})
+}
@@ -1,9 +1,33 @@
 package productController
 
+import models "github.com/wachirawittd123/shop-online-backend-golang/model"
+
 // AddProductRequest defines the expected structure of the request body
 type AddProductRequest struct {
-	Name       string `json:"name" binding:"required"`
-	Price      int    `json:"price" binding:"required"`
-	Detail     string `json:"detail"`
-	IDCategory string `json:"id_category" binding:"required"`
+	Name       string       `json:"name" binding:"required"`
+	Price      models.Money `json:"price" binding:"required"`
+	Detail     string       `json:"detail"`
+	IDCategory string       `json:"id_category" binding:"required"`
+	Stock      int          `json:"stock"` // Initial stock, only used when adding a product
+
+	Options  []models.ProductOption `json:"options"`                 // Option axes, required when the product has variants
+	Variants []VariantRequest       `json:"variants" binding:"dive"` // Leave empty to sell the product itself
+}
+
+// VariantRequest defines a product variant in an add or update request
+type VariantRequest struct {
+	ID      string            `json:"id"` // Existing variant to keep, empty for a new variant
+	SKU     string            `json:"sku" binding:"required"`
+	Barcode string            `json:"barcode"`
+	Options map[string]string `json:"options" binding:"required"`
+	Price   *models.Money     `json:"price"` // Defaults to the product price
+	Stock   int               `json:"stock"` // Initial stock, only used when the variant is added
+}
+
+// AdjustStockRequest defines the expected structure of a stock adjustment
+type AdjustStockRequest struct {
+	VariantID string `json:"variant_id"` // Required for products with variants
+	Delta     int    `json:"delta" binding:"required"`
+	Reason    string `json:"reason" binding:"required"`
+	Note      string `json:"note"`
 }
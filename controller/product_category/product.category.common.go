@@ -1,6 +1,7 @@
 package productCategoryController
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	models "github.com/wachirawittd123/shop-online-backend-golang/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // isCategoryNameTaken checks if the category name is already in use
@@ -56,3 +58,30 @@ func isCategoryInUse(categoryID primitive.ObjectID, c *gin.Context) bool {
 
 	return count > 0
 }
+
+// resolveTaxClass checks that a tax class ID refers to an existing class.
+// An empty ID resolves to the zero ID, meaning the default tax class.
+func resolveTaxClass(id string, c *gin.Context) (primitive.ObjectID, error) {
+	if id == "" {
+		return primitive.NilObjectID, nil
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid tax class ID format", err)
+		return objectID, err
+	}
+
+	collection, ctx := common.GetCollection("tax_classes")
+	defer ctx.Done()
+
+	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		common.RespondWithError(c, http.StatusBadRequest, "Tax class not found", err)
+		return objectID, err
+	}
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch tax class", err)
+	}
+	return objectID, err
+}
@@ -65,16 +65,23 @@ func GetProductsCategory(c *gin.Context) {
 }
 
 func AddProductCategory(c *gin.Context) {
-	var productCategory models.ProductCategory
-	if err := c.ShouldBindJSON(&productCategory); err != nil {
+	var requestBody RequestAddBody
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
 		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	if isCategoryNameTaken(productCategory.Name, c) {
+	if isCategoryNameTaken(requestBody.Name, c) {
+		return
+	}
+
+	taxClassID, err := resolveTaxClass(requestBody.TaxClassID, c)
+	if err != nil {
 		return
 	}
 
+	productCategory := models.ProductCategory{Name: requestBody.Name, TaxClassID: taxClassID}
+
 	if err := insertCategory(productCategory, c); err != nil {
 		return
 	}
@@ -94,10 +101,22 @@ func UpdateProductCategory(c *gin.Context) {
 		return
 	}
 
-	update := bson.M{"$set": bson.M{
+	set := bson.M{
 		"name":       requestBody.Name,
 		"updated_on": primitive.NewDateTimeFromTime(time.Now()),
-	}}
+	}
+	update := bson.M{"$set": set}
+	if requestBody.TaxClassID != nil {
+		taxClassID, err := resolveTaxClass(*requestBody.TaxClassID, c)
+		if err != nil {
+			return
+		}
+		if taxClassID.IsZero() {
+			update["$unset"] = bson.M{"tax_class_id": ""}
+		} else {
+			set["tax_class_id"] = taxClassID
+		}
+	}
 
 	if err := common.UpdateOneCommonInDB(objectID, update, c, "product_category"); err != nil {
 		return
@@ -1,5 +1,12 @@
 package productCategoryController
 
+// RequestAddBody defines the expected structure of a new product category
+type RequestAddBody struct {
+	Name       string `json:"name" binding:"required"`
+	TaxClassID string `json:"tax_class_id"` // Empty to use the default tax class
+}
+
 type RequestUpdateBody struct {
-	Name string `json:"name" binding:"required"`
+	Name       string  `json:"name" binding:"required"`
+	TaxClassID *string `json:"tax_class_id"` // Unchanged when omitted, the default tax class when empty
 }
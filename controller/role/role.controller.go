@@ -0,0 +1,61 @@
+package roleController
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+)
+
+// GetRoles lists every role with its permissions
+func GetRoles(c *gin.Context) {
+	roles, err := common.ListRolePermissions()
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch roles", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles, "permissions": models.AllPermissions, "status_code": http.StatusOK})
+}
+
+// UpdateRolePermissions replaces the permissions granted to a role
+func UpdateRolePermissions(c *gin.Context) {
+	role := c.Param("role")
+	if !models.IsValidRole(role) {
+		common.RespondWithError(c, http.StatusNotFound, "Role not found", nil)
+		return
+	}
+
+	var requestBody RequestUpdateRolePermissions
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	permissions := []string{}
+	seen := map[string]bool{}
+	for _, permission := range requestBody.Permissions {
+		if !models.IsValidPermission(permission) {
+			common.RespondWithError(c, http.StatusBadRequest, "Unknown permission: "+permission, nil)
+			return
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+
+	// Admins must keep the ability to manage roles or nobody could restore it
+	if role == models.RoleAdmin && !seen[models.PermRoleWrite] {
+		common.RespondWithError(c, http.StatusConflict, "The admin role must keep "+models.PermRoleWrite, nil)
+		return
+	}
+
+	if err := common.SetRolePermissions(role, permissions); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to update role permissions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role permissions updated successfully", "role": role, "permissions": permissions, "status_code": http.StatusOK})
+}
@@ -0,0 +1,5 @@
+package roleController
+
+type RequestUpdateRolePermissions struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
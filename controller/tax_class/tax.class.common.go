@@ -0,0 +1,75 @@
+package taxClassController
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// taxClassCodePattern restricts codes to values usable in the configuration
+var taxClassCodePattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// validateTaxClass checks the rate of a tax class
+func validateTaxClass(requestBody RequestUpdateTaxClass) error {
+	if requestBody.RateBasisPoints < 0 || requestBody.RateBasisPoints > 10000 {
+		return fmt.Errorf("rate_basis_points must be between 0 and 10000")
+	}
+	if requestBody.Exempt && requestBody.RateBasisPoints != 0 {
+		return fmt.Errorf("exempt tax classes must have a rate of 0")
+	}
+	return nil
+}
+
+// isTaxClassCodeTaken checks if the tax class code is already in use
+func isTaxClassCodeTaken(code string, c *gin.Context) bool {
+	collection, ctx := common.GetCollection("tax_classes")
+	defer ctx.Done()
+
+	count, err := collection.CountDocuments(ctx, bson.M{"code": code})
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to check tax class code", err)
+		return true
+	}
+	if count > 0 {
+		common.RespondWithError(c, http.StatusConflict, "Tax class code already in use", nil)
+		return true
+	}
+	return false
+}
+
+// isTaxClassInUse checks if the configuration or a product category refers
+// to the tax class, responding when it does
+func isTaxClassInUse(classID primitive.ObjectID, c *gin.Context) bool {
+	collection, ctx := common.GetCollection("tax_classes")
+	defer ctx.Done()
+
+	var class models.TaxClass
+	if err := collection.FindOne(ctx, bson.M{"_id": classID}).Decode(&class); err != nil {
+		// Let the delete report a missing class
+		return false
+	}
+	if class.Code == common.AppConfig.DefaultTaxClass || class.Code == common.AppConfig.DeliveryTaxClass {
+		common.RespondWithError(c, http.StatusConflict, "Tax class is set in the store configuration", nil)
+		return true
+	}
+
+	categories, ctx := common.GetCollection("product_category")
+	defer ctx.Done()
+
+	count, err := categories.CountDocuments(ctx, bson.M{"tax_class_id": classID})
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to check tax class usage", err)
+		return true
+	}
+	if count > 0 {
+		common.RespondWithError(c, http.StatusConflict, "Tax class is in use by product categories", nil)
+		return true
+	}
+	return false
+}
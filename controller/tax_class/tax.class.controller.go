@@ -0,0 +1,138 @@
+package taxClassController
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetTaxClasses lists the tax classes with the store's tax settings
+func GetTaxClasses(c *gin.Context) {
+	collection, ctx := common.GetCollection("tax_classes")
+	defer ctx.Done()
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"code": 1}))
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch tax classes", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var classes []models.TaxClass
+	if err := cursor.All(ctx, &classes); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to decode tax classes", err)
+		return
+	}
+
+	if classes == nil {
+		classes = []models.TaxClass{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tax_classes":        classes,
+		"prices_include_tax": common.AppConfig.PricesIncludeTax,
+		"default_tax_class":  common.AppConfig.DefaultTaxClass,
+		"delivery_tax_class": common.AppConfig.DeliveryTaxClass,
+		"status_code":        http.StatusOK,
+	})
+}
+
+// AddTaxClass adds a tax class
+func AddTaxClass(c *gin.Context) {
+	var requestBody RequestAddTaxClass
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if !taxClassCodePattern.MatchString(requestBody.Code) || requestBody.Code == common.DeliveryTaxProportional {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid tax class code", nil)
+		return
+	}
+	if err := validateTaxClass(requestBody.RequestUpdateTaxClass); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid tax class", err)
+		return
+	}
+
+	if isTaxClassCodeTaken(requestBody.Code, c) {
+		return
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	class := models.TaxClass{
+		ID:              primitive.NewObjectID(),
+		Code:            requestBody.Code,
+		Name:            requestBody.Name,
+		RateBasisPoints: requestBody.RateBasisPoints,
+		Exempt:          requestBody.Exempt,
+		CreatedAt:       now,
+		UpdatedOn:       now,
+	}
+
+	collection, ctx := common.GetCollection("tax_classes")
+	defer ctx.Done()
+
+	if _, err := collection.InsertOne(ctx, class); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to add tax class", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tax class added successfully", "tax_class": class, "status_code": http.StatusOK})
+}
+
+// UpdateTaxClass changes the name and rate of a tax class. Carts are taxed
+// at the new rate when next priced; placed orders keep the rate they had.
+func UpdateTaxClass(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	var requestBody RequestUpdateTaxClass
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := validateTaxClass(requestBody); err != nil {
+		common.RespondWithError(c, http.StatusBadRequest, "Invalid tax class", err)
+		return
+	}
+
+	update := bson.M{"$set": bson.M{
+		"name":              requestBody.Name,
+		"rate_basis_points": requestBody.RateBasisPoints,
+		"exempt":            requestBody.Exempt,
+		"updated_on":        primitive.NewDateTimeFromTime(time.Now()),
+	}}
+
+	if err := common.UpdateOneCommonInDB(objectID, update, c, "tax_classes"); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tax class updated successfully", "status_code": http.StatusOK})
+}
+
+// RemoveTaxClass deletes a tax class no category or setting refers to
+func RemoveTaxClass(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	if isTaxClassInUse(objectID, c) {
+		return
+	}
+
+	if err := common.DeleteOneCommonByID(objectID, c, "tax_classes"); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tax class deleted successfully", "status_code": http.StatusOK})
+}
@@ -0,0 +1,15 @@
+package taxClassController
+
+// RequestAddTaxClass defines the expected structure of a new tax class
+type RequestAddTaxClass struct {
+	Code string `json:"code" binding:"required"` // Lowercase letters, digits and underscores, e.g. "vat7"
+	RequestUpdateTaxClass
+}
+
+// RequestUpdateTaxClass defines the editable fields of a tax class. The code
+// is fixed once created since the configuration refers to classes by code.
+type RequestUpdateTaxClass struct {
+	Name            string `json:"name" binding:"required"`
+	RateBasisPoints int    `json:"rate_basis_points"` // 700 is 7%
+	Exempt          bool   `json:"exempt"`
+}
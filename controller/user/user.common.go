@@ -1,6 +1,7 @@
 package userController
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	models "github.com/wachirawittd123/shop-online-backend-golang/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // validateUserRole validates and sets the user's role
@@ -16,7 +18,7 @@ func validateUserRole(user *models.User, c *gin.Context) error {
 	errRole := user.SetRole(user.Role)
 	if errRole != "" {
 		common.RespondWithError(c, http.StatusConflict, errRole, nil)
-		return nil
+		return errors.New(errRole)
 	}
 	return nil
 }
@@ -36,7 +38,7 @@ func isEmailInUse(email string, c *gin.Context) bool {
 }
 
 // insertUser sets additional fields and inserts the user into the database
-func insertUser(user models.User, c *gin.Context) error {
+func insertUser(user models.User, c *gin.Context) (models.User, error) {
 	collection, ctx := common.GetCollection("users")
 	defer ctx.Done()
 
@@ -45,9 +47,39 @@ func insertUser(user models.User, c *gin.Context) error {
 	user.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
 
 	_, err := collection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		common.RespondWithError(c, http.StatusConflict, "Email already in use", nil)
+		return user, err
+	}
 	if err != nil {
 		common.RespondWithError(c, http.StatusInternalServerError, "Failed to add user", err)
-		return err
+		return user, err
 	}
-	return nil
+	return user, nil
+}
+
+// authEventsLimit caps how many auth events are returned for a user
+const authEventsLimit = 100
+
+// findUser retrieves a user by ID
+func findUser(userID primitive.ObjectID, c *gin.Context) (models.User, bool) {
+	collection, ctx := common.GetCollection("users")
+	defer ctx.Done()
+
+	var user models.User
+	if err := collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		common.RespondWithError(c, http.StatusNotFound, "User not found", err)
+		return user, false
+	}
+	return user, true
+}
+
+// canActOnUser reports whether the caller may change the given user, either
+// through user:write or because it is their own account
+func canActOnUser(c *gin.Context, userID primitive.ObjectID) bool {
+	if common.HasPermission(c, models.PermUserWrite) {
+		return true
+	}
+	callerID, err := common.GetUserIDFromContext(c)
+	return err == nil && callerID == userID
 }
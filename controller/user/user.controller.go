@@ -62,23 +62,32 @@ func GetUsers(c *gin.Context) {
 		return
 	}
 
-	if users == nil {
-		users = []models.User{}
+	response := make([]models.UserResponse, 0, len(users))
+	for _, user := range users {
+		response = append(response, user.ToResponse())
 	}
 
-	c.JSON(http.StatusOK, gin.H{"users": users, "status_code": http.StatusOK})
+	c.JSON(http.StatusOK, gin.H{"users": response, "status_code": http.StatusOK})
 }
 
 // AddUser adds a new user to the database
 func AddUser(c *gin.Context) {
-	var user models.User
+	var requestBody RequestAddUser
 
 	// Parse and validate the request body
-	if err := c.ShouldBindJSON(&user); err != nil {
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
 		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
+	user := models.User{
+		Name:         requestBody.Name,
+		Email:        requestBody.Email,
+		Password:     requestBody.Password,
+		Role:         requestBody.Role,
+		ShippingAddr: requestBody.ShippingAddr,
+	}
+
 	// Validate and set the role
 	if err := validateUserRole(&user, c); err != nil {
 		return
@@ -98,11 +107,12 @@ func AddUser(c *gin.Context) {
 	user.Password = hashedPassword
 
 	// Set additional fields and insert the user
-	if err := insertUser(user, c); err != nil {
+	user, err = insertUser(user, c)
+	if err != nil {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User added successfully", "status_code": http.StatusOK})
+	c.JSON(http.StatusOK, gin.H{"message": "User added successfully", "user": user.ToResponse(), "status_code": http.StatusOK})
 }
 
 func RemoveUser(c *gin.Context) {
@@ -115,6 +125,12 @@ func RemoveUser(c *gin.Context) {
 		return
 	}
 
+	// The refresh tokens of a deleted user must not mint new access tokens
+	if err := common.RevokeUserSessions(objectID, primitive.NilObjectID, models.SessionRevokedUserDeleted); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "User deleted but failed to revoke their sessions", err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully", "status_code": http.StatusOK})
 }
 
@@ -125,6 +141,12 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// Without user:write callers can only update their own profile
+	if !canActOnUser(c, objectID) {
+		common.RespondWithError(c, http.StatusForbidden, "You can only update your own profile", nil)
+		return
+	}
+
 	var requestBody RequestUpdateBody
 	if err := c.ShouldBindJSON(&requestBody); err != nil {
 		common.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
@@ -151,3 +173,46 @@ func UpdateUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "User successfully", "status_code": http.StatusOK})
 }
+
+// UnlockUser clears the failed login attempts locking a user out
+func UnlockUser(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	user, ok := findUser(objectID, c)
+	if !ok {
+		return
+	}
+
+	actor, err := common.ActorFromContext(c)
+	if err != nil {
+		common.RespondWithError(c, http.StatusUnauthorized, "User not authenticated", err)
+		return
+	}
+
+	if err := common.ResetLoginFailures(user.Email); err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to unlock user", err)
+		return
+	}
+	common.RecordAuthEvent(c, models.AuthEvent{Type: models.AuthEventAccountUnlocked, UserID: user.ID, Email: user.Email, ActorID: actor.ID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked successfully", "status_code": http.StatusOK})
+}
+
+// GetUserAuthEvents lists a user's recent authentication events
+func GetUserAuthEvents(c *gin.Context) {
+	objectID, err := common.ConvertIDMongodb(c.Param("id"), c)
+	if err != nil {
+		return
+	}
+
+	events, err := common.ListAuthEvents(objectID, authEventsLimit)
+	if err != nil {
+		common.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch auth events", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events, "status_code": http.StatusOK})
+}
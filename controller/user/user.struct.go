@@ -8,3 +8,12 @@ type RequestUpdateBody struct {
 	Name         string                 `json:"name"`
 	ShippingAddr models.ShippingAddress `json:"address"`
 }
+
+// RequestAddUser defines the body an admin creates a user with
+type RequestAddUser struct {
+	Name         string                 `json:"name" binding:"required"`
+	Email        string                 `json:"email" binding:"required,email"`
+	Password     string                 `json:"password" binding:"required,min=8"`
+	Role         string                 `json:"role" binding:"required"`
+	ShippingAddr models.ShippingAddress `json:"address"`
+}
@@ -12,15 +12,31 @@ import (
 
 func main() {
 	common.LoadConfig()
+	common.InitJWTKeys()
 	// Connect to the database
 	db := common.ConnectDB(common.AppConfig.MongoURI, "shop-online")
 
 	// Example: Use `db` to access collections
 	log.Println("Database initialized:", db.Name())
+	common.EnsureIndexes(db)
+	common.SeedRolePermissions()
+	common.SeedTaxClasses()
+	common.CheckStockTracked()
+
+	// Share revoked tokens between replicas and drop expired ones
+	common.InitTokenBlacklist()
+	common.StartBlacklistSweeper(common.AppConfig.BlacklistSweepEvery)
+	common.InitMailer()
 
 	// Initialize Gin
 	r := gin.Default()
 
+	// Client IPs feed API key allowlists and login throttling, so only
+	// believe X-Forwarded-For from our own proxies
+	if err := r.SetTrustedProxies(common.AppConfig.TrustedProxies); err != nil {
+		log.Fatalf("Invalid value for TRUSTED_PROXIES: %v", err)
+	}
+
 	routes.RegisterRoutes(r)
 
 	// Example route
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKeyActorRole is recorded as the actor role of changes made with an API key
+const APIKeyActorRole = "api_key"
+
+// APIKey lets an integration call the API without a user account. The key
+// itself is only shown once; "sk_<prefix>_<secret>" is looked up by prefix
+// and checked against the hash of the whole key.
+type APIKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Name       string             `bson:"name"`
+	Prefix     string             `bson:"prefix"`            // Public part identifying the key
+	KeyHash    string             `bson:"key_hash" json:"-"` // SHA-256 of the full key
+	Scopes     []string           `bson:"scopes"`            // Permissions granted to the key
+	AllowedIPs []string           `bson:"allowed_ips"`       // IPs or CIDR ranges the key may be used from; empty allows any
+	CreatedBy  primitive.ObjectID `bson:"created_by"`
+	CreatedAt  primitive.DateTime `bson:"created_at"`
+	ExpiresAt  primitive.DateTime `bson:"expires_at,omitempty"` // Empty for keys that don't expire
+	LastUsedAt primitive.DateTime `bson:"last_used_at,omitempty"`
+	LastUsedIP string             `bson:"last_used_ip,omitempty"`
+	RevokedAt  primitive.DateTime `bson:"revoked_at,omitempty"`
+}
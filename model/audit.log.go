@@ -0,0 +1,27 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditLog records an action taken on behalf of a user by someone else
+type AuditLog struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Action     string             `bson:"action"`
+	ActorID    primitive.ObjectID `bson:"actor_id"` // Admin who acted
+	UserID     primitive.ObjectID `bson:"user_id"`  // User acted as
+	SessionID  primitive.ObjectID `bson:"session_id,omitempty"`
+	Method     string             `bson:"method,omitempty"`
+	Path       string             `bson:"path,omitempty"`
+	StatusCode int                `bson:"status_code,omitempty"`
+	IP         string             `bson:"ip"`
+	UserAgent  string             `bson:"user_agent"`
+	CreatedAt  primitive.DateTime `bson:"created_at"`
+}
+
+// Predefined audit log actions
+const (
+	AuditImpersonationStarted = "impersonation_started"
+	AuditImpersonationEnded   = "impersonation_ended"
+	AuditImpersonatedRequest  = "impersonated_request" // A request made with an impersonation token
+)
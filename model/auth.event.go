@@ -0,0 +1,29 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuthEvent is an entry in the authentication event log
+type AuthEvent struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Type      string             `bson:"type"`
+	UserID    primitive.ObjectID `bson:"user_id,omitempty"`  // Empty when the email matched no account
+	Email     string             `bson:"email,omitempty"`    // Email the attempt was made for
+	ActorID   primitive.ObjectID `bson:"actor_id,omitempty"` // Admin who performed the action, if any
+	IP        string             `bson:"ip"`
+	UserAgent string             `bson:"user_agent"`
+	Detail    string             `bson:"detail,omitempty"`
+	CreatedAt primitive.DateTime `bson:"created_at"`
+}
+
+// Predefined authentication event types
+const (
+	AuthEventLoginSucceeded  = "login_succeeded"
+	AuthEventLoginFailed     = "login_failed"
+	AuthEventLoginThrottled  = "login_throttled"   // Attempt refused while locked out
+	AuthEventTwoFactorFailed = "two_factor_failed" // Wrong authenticator or recovery code
+	AuthEventAccountLocked   = "account_locked"
+	AuthEventAccountUnlocked = "account_unlocked"
+	AuthEventIPAddressLocked = "ip_locked"
+)
@@ -9,19 +9,34 @@ type Cart struct {
 	ID       primitive.ObjectID `bson:"_id,omitempty"`
 	UserID   primitive.ObjectID `bson:"user_id,omitempty"`
 	Items    []CartItem         `bson:"items"`     // List of items in the cart
-	Status   string             `bson:"status"`    // e.g., "active", "completed", "cancelled"
-	SubTotal float64            `bson:"sub_total"` // Total of item prices before discounts or taxes
-	Total    float64            `bson:"total"`     // Final total after discounts and taxes
+	Status   string             `bson:"status"`    // One of the CartStatus constants
+	SubTotal Money              `bson:"sub_total"` // Total of item prices at catalog prices, before discounts
+	Total    Money              `bson:"total"`     // Final total after discounts and taxes
 	// Discount  float64            `bson:"discount"`   // Total discount applied to the cart
-	// Tax       float64            `bson:"tax"`        // Total tax applied to the cart
-	DeliveryFee float64            `bson:"delivery_fee"` // Cost of delivery
-	CreatedAt   primitive.DateTime `bson:"created_at"`   // Timestamp when the cart was created
-	UpdatedOn   primitive.DateTime `bson:"updated_on"`   // Timestamp when the cart was last updated
+	Tax              Money              `bson:"tax"`                // Tax on the items and the delivery fee
+	DeliveryTax      Money              `bson:"delivery_tax"`       // Part of Tax charged on the delivery fee
+	TaxLines         []TaxLine          `bson:"tax_lines"`          // Tax per tax class
+	PricesIncludeTax bool               `bson:"prices_include_tax"` // Whether SubTotal and DeliveryFee already include Tax
+	DeliveryFee      Money              `bson:"delivery_fee"`       // Cost of delivery
+	CreatedAt        primitive.DateTime `bson:"created_at"`         // Timestamp when the cart was created
+	UpdatedOn        primitive.DateTime `bson:"updated_on"`         // Timestamp when the cart was last updated
 }
 
 // CartItem represents an individual item in the shopping cart
 type CartItem struct {
 	ProductID primitive.ObjectID `bson:"product_id"`
+	VariantID primitive.ObjectID `bson:"variant_id,omitempty"` // Chosen variant, required for products with variants
 	Qty       int                `bson:"qty"`
-	Total     float64            `bson:"total"`
+	Total     Money              `bson:"total"`                 // Quantity times the catalog price
+	TaxClass  string             `bson:"tax_class,omitempty"`   // Code of the tax class applied
+	TaxRate   int                `bson:"tax_rate_basis_points"` // Rate of the tax class when priced
+	Net       Money              `bson:"net"`                   // Line total excluding tax
+	Tax       Money              `bson:"tax"`
 }
+
+// Predefined cart status constants
+const (
+	CartStatusActive    = "active"    // Cart is being filled by the user
+	CartStatusCompleted = "completed" // Cart has been checked out into an order
+	CartStatusCancelled = "cancelled" // Cart has been abandoned
+)
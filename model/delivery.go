@@ -6,17 +6,31 @@ import (
 
 // Delivery represents a delivery document in the database
 type Delivery struct {
-	ID               primitive.ObjectID `bson:"_id,omitempty"`           // Unique identifier for the delivery
-	OrderID          primitive.ObjectID `bson:"order_id"`                // Reference to the cart/order ID
-	UserID           primitive.ObjectID `bson:"user_id"`                 // Reference to the user
-	DeliveryPersonID primitive.ObjectID `bson:"delivery_person_id"`      // Reference to the delivery person
-	Status           string             `bson:"status"`                  // Delivery status (e.g., "pending", "shipped", "delivered")
-	TrackingCode     string             `bson:"tracking_code,omitempty"` // Optional tracking code from the courier service
-	DeliveryFee      float64            `bson:"delivery_fee"`            // Cost of delivery
-	CreatedAt        primitive.DateTime `bson:"created_at"`              // Timestamp for when the delivery was created
-	UpdatedOn        primitive.DateTime `bson:"updated_on"`              // Timestamp for when the delivery was last updated
-	// ExpectedDate     primitive.DateTime `bson:"expected_date,omitempty"`  // Expected delivery date
-	DeliveredDate primitive.DateTime `bson:"delivered_date"` // Actual delivery date
+	ID               primitive.ObjectID     `bson:"_id,omitempty"`               // Unique identifier for the delivery
+	OrderID          primitive.ObjectID     `bson:"order_id"`                    // Reference to the order ID
+	UserID           primitive.ObjectID     `bson:"user_id"`                     // Reference to the user
+	DeliveryPersonID primitive.ObjectID     `bson:"delivery_person_id"`          // Reference to the delivery person
+	AssignmentStatus string                 `bson:"assignment_status,omitempty"` // One of the Assignment constants
+	AssignmentNote   string                 `bson:"assignment_note,omitempty"`   // Reason given when a courier rejects a job
+	Status           string                 `bson:"status"`                      // Delivery status (e.g., "pending", "shipped", "delivered")
+	TrackingCode     string                 `bson:"tracking_code,omitempty"`     // Optional tracking code from the courier service
+	DeliveryFee      Money                  `bson:"delivery_fee"`                // Cost of delivery
+	DistanceKm       float64                `bson:"distance_km"`                 // Distance from the store to the shipping address
+	History          []DeliveryStatusChange `bson:"status_history"`              // Every status transition, oldest first
+	CreatedAt        primitive.DateTime     `bson:"created_at"`                  // Timestamp for when the delivery was created
+	UpdatedOn        primitive.DateTime     `bson:"updated_on"`                  // Timestamp for when the delivery was last updated
+	ExpectedDate     primitive.DateTime     `bson:"expected_date,omitempty"`     // Expected delivery date
+	DeliveredDate    primitive.DateTime     `bson:"delivered_date"`              // Actual delivery date
+}
+
+// DeliveryStatusChange records a single delivery status transition
+type DeliveryStatusChange struct {
+	From      string             `bson:"from"`
+	To        string             `bson:"to"`
+	ActorID   primitive.ObjectID `bson:"actor_id"`   // User who made the change
+	ActorRole string             `bson:"actor_role"` // Role of the user who made the change
+	Note      string             `bson:"note,omitempty"`
+	ChangedAt primitive.DateTime `bson:"changed_at"`
 }
 
 // Predefined delivery status constants
@@ -28,3 +42,37 @@ const (
 	StatusCancelled  = "cancelled"   // Delivery has been cancelled
 	StatusFailed     = "failed"      // Delivery attempt was unsuccessful
 )
+
+// Predefined courier assignment status constants
+const (
+	AssignmentAssigned = "assigned" // Delivery has been offered to a courier
+	AssignmentAccepted = "accepted" // Courier has accepted the delivery
+	AssignmentRejected = "rejected" // Courier has rejected the delivery and it needs reassigning
+)
+
+// deliveryTransitions lists the statuses each delivery status may move to
+var deliveryTransitions = map[string][]string{
+	StatusPending:    {StatusInProgress, StatusCancelled},
+	StatusInProgress: {StatusShipped, StatusFailed, StatusCancelled},
+	StatusShipped:    {StatusDelivered, StatusFailed},
+	StatusFailed:     {StatusInProgress, StatusCancelled},
+}
+
+// IsValidDeliveryStatus checks if a status is a known delivery status
+func IsValidDeliveryStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusInProgress, StatusShipped, StatusDelivered, StatusCancelled, StatusFailed:
+		return true
+	}
+	return false
+}
+
+// CanTransitionDelivery checks if a delivery may move from one status to another
+func CanTransitionDelivery(from, to string) bool {
+	for _, next := range deliveryTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeliveryZone represents an area we ship to, with its own fee table
+type DeliveryZone struct {
+	ID                    primitive.ObjectID `bson:"_id,omitempty"`
+	Name                  string             `bson:"name"`
+	Area                  GeoPolygon         `bson:"area"`                    // GeoJSON polygon covered by the zone
+	BaseFee               Money              `bson:"base_fee"`                // Flat fee charged for every delivery
+	FeeTiers              []FeeTier          `bson:"fee_tiers"`               // Per-km pricing on top of the base fee
+	FreeShippingThreshold Money              `bson:"free_shipping_threshold"` // Sub total from which delivery is free, 0 disables it
+	LeadTimeDays          int                `bson:"lead_time_days"`          // Days from order to expected delivery
+	Active                bool               `bson:"active"`
+	CreatedAt             primitive.DateTime `bson:"created_at"`
+	UpdatedOn             primitive.DateTime `bson:"updated_on"`
+}
+
+// GeoPolygon is a GeoJSON polygon; coordinates are [longitude, latitude] pairs
+type GeoPolygon struct {
+	Type        string        `bson:"type" json:"type"`
+	Coordinates [][][]float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// GeoPoint is a GeoJSON point; coordinates are [longitude, latitude]
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// FeeTier charges PerKm for every kilometre up to UpToKm.
+// An UpToKm of 0 means the tier has no upper bound.
+type FeeTier struct {
+	UpToKm float64 `bson:"up_to_km" json:"up_to_km"`
+	PerKm  Money   `bson:"per_km" json:"per_km"`
+}
+
+// NewGeoPoint builds a GeoJSON point from a latitude and longitude
+func NewGeoPoint(latitude, longitude float64) GeoPoint {
+	return GeoPoint{Type: "Point", Coordinates: []float64{longitude, latitude}}
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExchangeRate converts store currency amounts into another currency for
+// display. A rate applies from its effective date until a later rate for
+// the same currency takes over.
+type ExchangeRate struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	Currency      string             `bson:"currency"`       // Currency prices are converted into
+	Rate          string             `bson:"rate"`           // Units of Currency per unit of the store currency, as an exact decimal
+	EffectiveFrom primitive.DateTime `bson:"effective_from"` // When the rate starts to apply
+	CreatedBy     primitive.ObjectID `bson:"created_by,omitempty"`
+	CreatedAt     primitive.DateTime `bson:"created_at"`
+}
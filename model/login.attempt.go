@@ -0,0 +1,15 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoginAttempt counts recent failed logins for an account or IP address
+type LoginAttempt struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	Key          string             `bson:"key"` // "account:<email>" or "ip:<address>"
+	Failures     int                `bson:"failures"`
+	LastFailedAt primitive.DateTime `bson:"last_failed_at"`
+	LockedUntil  primitive.DateTime `bson:"locked_until,omitempty"` // No attempts are allowed before this time
+	ExpiresAt    primitive.DateTime `bson:"expires_at"`             // The counter is forgotten after this time
+}
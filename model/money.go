@@ -0,0 +1,289 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Money is an amount in the minor units of a currency, e.g. satang for THB,
+// so sums are exact. Amounts computed with a fraction (distance rates,
+// exchange rates, tax) are rounded half away from zero to the nearest minor
+// unit, once, at the end of the calculation.
+//
+// Money is stored as {"amount": <minor units>, "currency": "THB"} and
+// rendered with the decimal amount in major units alongside, e.g.
+// {"amount": 1250, "currency": "THB", "value": "12.50"}.
+//
+// Requests give either "amount" in minor units or "value" in major units,
+// as a decimal string or number. A bare number is read as a value in
+// DefaultCurrency, which is how amounts were written before they carried a
+// currency; it is never minor units. When both "amount" and "value" are
+// given they must agree.
+type Money struct {
+	Amount   int64  `bson:"amount" json:"amount"`
+	Currency string `bson:"currency" json:"currency"`
+}
+
+// ErrCurrencyMismatch is returned when amounts in different currencies are combined
+var ErrCurrencyMismatch = errors.New("money: currency mismatch")
+
+// DefaultCurrency is the store currency, set from the configuration at startup
+var DefaultCurrency = "THB"
+
+// currencyExponents lists the supported ISO 4217 currencies and the number
+// of decimal places of their minor unit
+var currencyExponents = map[string]int{
+	"AUD": 2, "CAD": 2, "CHF": 2, "CNY": 2, "EUR": 2, "GBP": 2, "HKD": 2,
+	"IDR": 2, "INR": 2, "JPY": 0, "KRW": 0, "LAK": 2, "MMK": 2, "MYR": 2,
+	"NZD": 2, "PHP": 2, "SGD": 2, "THB": 2, "TWD": 2, "USD": 2, "VND": 0,
+}
+
+// IsValidCurrency checks if a currency code is supported
+func IsValidCurrency(code string) bool {
+	_, ok := currencyExponents[code]
+	return ok
+}
+
+// CurrencyExponent returns the number of decimal places of a currency
+func CurrencyExponent(code string) int {
+	if exponent, ok := currencyExponents[code]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// NewMoney returns an amount given in minor units
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// MoneyFromFloat converts a decimal amount, rounding to the minor unit
+func MoneyFromFloat(value float64, currency string) Money {
+	scale := math.Pow10(CurrencyExponent(currency))
+	return Money{Amount: int64(math.Round(value * scale)), Currency: currency}
+}
+
+// MoneyFromRat rounds an exact number of minor units, e.g. a sum of
+// fractional per-km charges
+func MoneyFromRat(minorUnits *big.Rat, currency string) Money {
+	return Money{Amount: roundRat(minorUnits), Currency: currency}
+}
+
+// ParseMoney parses a decimal amount such as "125.50". More decimal places
+// than the currency has are rejected rather than rounded away.
+func ParseMoney(value string, currency string) (Money, error) {
+	exponent := CurrencyExponent(currency)
+	value = strings.TrimSpace(value)
+
+	negative := strings.HasPrefix(value, "-")
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(value, "-"), ".")
+	if whole == "" || len(fraction) > exponent || strings.ContainsAny(whole+fraction, "+-eE") {
+		return Money{}, fmt.Errorf("invalid %s amount %q", currency, value)
+	}
+
+	amount, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", exponent-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid %s amount %q", currency, value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns the sum of two amounts in the same currency
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.commonCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+// Sub returns the difference of two amounts in the same currency
+func (m Money) Sub(other Money) (Money, error) {
+	currency, err := m.commonCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}, nil
+}
+
+// Cmp compares two amounts in the same currency, returning -1, 0 or +1
+func (m Money) Cmp(other Money) (int, error) {
+	if _, err := m.commonCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Mul multiplies the amount by a quantity
+func (m Money) Mul(qty int) Money {
+	return Money{Amount: m.Amount * int64(qty), Currency: m.Currency}
+}
+
+// MulRat multiplies the amount by an exact fraction, rounding the result
+func (m Money) MulRat(factor *big.Rat) Money {
+	return MoneyFromRat(new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), factor), m.Currency)
+}
+
+// Decimal formats the amount with the currency's decimal places, e.g. "125.50"
+func (m Money) Decimal() string {
+	exponent := CurrencyExponent(m.Currency)
+	if exponent == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	digits := fmt.Sprintf("%0*d", exponent+1, amount)
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// String formats the amount with its currency, e.g. "125.50 THB"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// commonCurrency returns the currency shared by two amounts. A zero amount
+// without a currency takes the other's, so sums can start from Money{}.
+func (m Money) commonCurrency(other Money) (string, error) {
+	switch {
+	case m.Currency == other.Currency:
+		return m.Currency, nil
+	case m.Currency == "" && m.Amount == 0:
+		return other.Currency, nil
+	case other.Currency == "" && other.Amount == 0:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+}
+
+// roundRat rounds a fraction half away from zero to an integer
+func roundRat(value *big.Rat) int64 {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	twice := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2))
+	if twice.Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+	return quotient.Int64()
+}
+
+// MarshalJSON renders the amount in minor units and its decimal value
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+		Value    string `json:"value"`
+	}{m.Amount, m.Currency, m.Decimal()})
+}
+
+// UnmarshalJSON reads {"amount": <minor units>, "currency": ...},
+// {"value": <major units>, "currency": ...} or a bare number in major units
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := strings.TrimSpace(string(data))
+	if text == "null" {
+		return nil
+	}
+
+	if !strings.HasPrefix(text, "{") {
+		if strings.HasPrefix(text, `"`) {
+			return fmt.Errorf("amount must be a number or an object, not a string")
+		}
+		parsed, err := ParseMoney(text, DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	var decoded struct {
+		Amount   *int64          `json:"amount"`
+		Value    json.RawMessage `json:"value"`
+		Currency string          `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Currency == "" {
+		decoded.Currency = DefaultCurrency
+	}
+	if !IsValidCurrency(decoded.Currency) {
+		return fmt.Errorf("unsupported currency %q", decoded.Currency)
+	}
+
+	switch {
+	case decoded.Value != nil && string(decoded.Value) != "null":
+		var value string
+		if err := json.Unmarshal(decoded.Value, &value); err != nil {
+			value = string(decoded.Value) // A number rather than a decimal string
+		}
+		parsed, err := ParseMoney(value, decoded.Currency)
+		if err != nil {
+			return err
+		}
+		if decoded.Amount != nil && *decoded.Amount != parsed.Amount {
+			return fmt.Errorf("amount %d and value %q disagree", *decoded.Amount, value)
+		}
+		*m = parsed
+	case decoded.Amount != nil:
+		*m = Money{Amount: *decoded.Amount, Currency: decoded.Currency}
+	default:
+		return fmt.Errorf("an amount or a value is required")
+	}
+	return nil
+}
+
+// UnmarshalBSONValue reads a money document, or a bare number stored before
+// the money migration ran
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	value := bsoncore.Value{Type: t, Data: data}
+	switch t {
+	case bsontype.EmbeddedDocument:
+		type money Money
+		var decoded money
+		if err := bson.Unmarshal(data, &decoded); err != nil {
+			return err
+		}
+		*m = Money(decoded)
+	case bsontype.Double:
+		*m = MoneyFromFloat(value.Double(), DefaultCurrency)
+	case bsontype.Int32:
+		*m = MoneyFromFloat(float64(value.Int32()), DefaultCurrency)
+	case bsontype.Int64:
+		*m = MoneyFromFloat(float64(value.Int64()), DefaultCurrency)
+	case bsontype.Null, bsontype.Undefined:
+		*m = Money{}
+	default:
+		return fmt.Errorf("cannot decode %s into money", t)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OIDCState tracks an OpenID Connect login between the redirect to the
+// provider and its callback
+type OIDCState struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	StateHash    string             `bson:"state_hash" json:"-"` // SHA-256 of the state parameter
+	Provider     string             `bson:"provider"`
+	Nonce        string             `bson:"nonce" json:"-"`         // Must come back in the ID token
+	CodeVerifier string             `bson:"code_verifier" json:"-"` // PKCE verifier for the token exchange
+	CreatedAt    primitive.DateTime `bson:"created_at"`
+	ExpiresAt    primitive.DateTime `bson:"expires_at"`
+}
+
+// OIDCLoginCode is handed to the frontend after a provider sign in, which
+// exchanges it once for the user's session
+type OIDCLoginCode struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	CodeHash  string             `bson:"code_hash" json:"-"` // SHA-256 of the code
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Provider  string             `bson:"provider"`
+	CreatedAt primitive.DateTime `bson:"created_at"`
+	ExpiresAt primitive.DateTime `bson:"expires_at"`
+}
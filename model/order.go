@@ -0,0 +1,100 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Order represents a placed order document in the database.
+// Items, prices and the shipping address are snapshots taken at checkout
+// so later catalog or profile edits don't rewrite order history.
+type Order struct {
+	ID                   primitive.ObjectID  `bson:"_id,omitempty"`
+	UserID               primitive.ObjectID  `bson:"user_id"`
+	CartID               primitive.ObjectID  `bson:"cart_id"`          // Cart the order was checked out from
+	Items                []OrderItem         `bson:"items"`            // Snapshot of the ordered items
+	ShippingAddr         ShippingAddress     `bson:"shipping_address"` // Snapshot of the user's shipping address
+	Status               string              `bson:"status"`
+	SubTotal             Money               `bson:"sub_total"`
+	DeliveryFee          Money               `bson:"delivery_fee"`
+	DistanceKm           float64             `bson:"distance_km"`            // Distance the delivery fee was computed from
+	ZoneID               primitive.ObjectID  `bson:"zone_id,omitempty"`      // Delivery zone the address fell in
+	ExpectedDeliveryDate primitive.DateTime  `bson:"expected_delivery_date"` // Order date plus the zone lead time
+	Tax                  Money               `bson:"tax"`                    // Tax on the items and the delivery fee
+	DeliveryTax          Money               `bson:"delivery_tax"`           // Part of Tax charged on the delivery fee
+	TaxLines             []TaxLine           `bson:"tax_lines"`              // Tax per tax class
+	PricesIncludeTax     bool                `bson:"prices_include_tax"`     // Whether SubTotal and DeliveryFee already include Tax
+	Total                Money               `bson:"total"`
+	DisplayCurrency      string              `bson:"display_currency"`           // Currency the customer saw prices in
+	ExchangeRate         string              `bson:"exchange_rate"`              // Store to display currency rate in effect at checkout
+	ExchangeRateID       primitive.ObjectID  `bson:"exchange_rate_id,omitempty"` // Stored rate used, empty for the store currency
+	DisplayTotal         Money               `bson:"display_total"`              // Total converted with ExchangeRate
+	History              []OrderStatusChange `bson:"status_history"`             // Every status transition, oldest first
+	CreatedAt            primitive.DateTime  `bson:"created_at"`
+	UpdatedOn            primitive.DateTime  `bson:"updated_on"`
+}
+
+// OrderItem represents an item snapshot in an order
+type OrderItem struct {
+	ProductID primitive.ObjectID `bson:"product_id"`
+	VariantID primitive.ObjectID `bson:"variant_id,omitempty"`
+	Name      string             `bson:"name"`              // Product name at checkout
+	SKU       string             `bson:"sku,omitempty"`     // Variant SKU at checkout
+	Options   map[string]string  `bson:"options,omitempty"` // Variant option values at checkout
+	UnitPrice Money              `bson:"unit_price"`        // Product price at checkout
+	Qty       int                `bson:"qty"`
+	Total     Money              `bson:"total"`
+	TaxClass  string             `bson:"tax_class,omitempty"`   // Code of the tax class applied
+	TaxRate   int                `bson:"tax_rate_basis_points"` // Tax rate at checkout
+	Net       Money              `bson:"net"`                   // Line total excluding tax
+	Tax       Money              `bson:"tax"`
+}
+
+// OrderStatusChange records a single order status transition
+type OrderStatusChange struct {
+	From      string             `bson:"from"`
+	To        string             `bson:"to"`
+	ActorID   primitive.ObjectID `bson:"actor_id"`   // User who made the change
+	ActorRole string             `bson:"actor_role"` // Role of the user who made the change
+	Note      string             `bson:"note,omitempty"`
+	ChangedAt primitive.DateTime `bson:"changed_at"`
+}
+
+// Predefined order status constants
+const (
+	OrderStatusPendingPayment = "pending_payment" // Order has been placed and awaits payment
+	OrderStatusPaid           = "paid"            // Payment has been received
+	OrderStatusFulfilling     = "fulfilling"      // Order is being picked and packed
+	OrderStatusShipped        = "shipped"         // Order has been handed to delivery
+	OrderStatusDelivered      = "delivered"       // Order has reached the customer
+	OrderStatusCancelled      = "cancelled"       // Order was cancelled before shipping
+	OrderStatusRefunded       = "refunded"        // Payment was returned to the customer
+)
+
+// orderTransitions lists the statuses each order status may move to
+var orderTransitions = map[string][]string{
+	OrderStatusPendingPayment: {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:           {OrderStatusFulfilling, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusFulfilling:     {OrderStatusShipped, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusShipped:        {OrderStatusDelivered},
+	OrderStatusDelivered:      {OrderStatusRefunded},
+}
+
+// IsValidOrderStatus checks if a status is a known order status
+func IsValidOrderStatus(status string) bool {
+	switch status {
+	case OrderStatusPendingPayment, OrderStatusPaid, OrderStatusFulfilling, OrderStatusShipped,
+		OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// CanTransitionOrder checks if an order may move from one status to another
+func CanTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasswordReset represents a single-use password reset token
+type PasswordReset struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	TokenHash string             `bson:"token_hash" json:"-"` // SHA-256 of the token sent by email
+	ExpiresAt primitive.DateTime `bson:"expires_at"`
+	UsedAt    primitive.DateTime `bson:"used_at,omitempty"` // Set once the token has been redeemed
+	CreatedAt primitive.DateTime `bson:"created_at"`
+}
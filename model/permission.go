@@ -0,0 +1,125 @@
+package models
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Permissions granted to roles. ":own" and ":self" permissions only allow
+// acting on the caller's own resources.
+const (
+	PermAccountSelf       = "account:self" // Sessions, password and 2FA of the caller
+	PermUserRead          = "user:read"
+	PermUserWrite         = "user:write"
+	PermUserUpdateSelf    = "user:update:self"
+	PermUserImpersonate   = "user:impersonate"
+	PermRoleRead          = "role:read"
+	PermRoleWrite         = "role:write"
+	PermAPIKeyManage      = "api_key:manage"
+	PermProductRead       = "product:read"
+	PermProductWrite      = "product:write"
+	PermInventoryRead     = "inventory:read"
+	PermInventoryWrite    = "inventory:write"
+	PermCategoryRead      = "category:read"
+	PermCategoryWrite     = "category:write"
+	PermCartRead          = "cart:read"
+	PermCartWriteOwn      = "cart:write:own"
+	PermCartReadOwn       = "cart:read:own"
+	PermOrderCreate       = "order:create"
+	PermOrderRead         = "order:read"
+	PermOrderReadOwn      = "order:read:own"
+	PermOrderUpdate       = "order:update"
+	PermOrderCancelOwn    = "order:cancel:own"
+	PermDeliveryRead      = "delivery:read"
+	PermDeliveryReadOwn   = "delivery:read:own"
+	PermDeliveryAssign    = "delivery:assign"
+	PermDeliveryUpdate    = "delivery:update"
+	PermDeliveryCourier   = "delivery:courier" // Work on deliveries assigned to the caller
+	PermDeliveryZoneRead  = "delivery_zone:read"
+	PermDeliveryZoneWrite = "delivery_zone:write"
+	PermExchangeRateRead  = "exchange_rate:read"
+	PermExchangeRateWrite = "exchange_rate:write"
+	PermTaxClassRead      = "tax_class:read"
+	PermTaxClassWrite     = "tax_class:write"
+)
+
+// AllPermissions lists every permission that can be granted
+var AllPermissions = []string{
+	PermAccountSelf,
+	PermUserRead, PermUserWrite, PermUserUpdateSelf, PermUserImpersonate,
+	PermRoleRead, PermRoleWrite,
+	PermAPIKeyManage,
+	PermProductRead, PermProductWrite,
+	PermInventoryRead, PermInventoryWrite,
+	PermCategoryRead, PermCategoryWrite,
+	PermCartRead, PermCartReadOwn, PermCartWriteOwn,
+	PermOrderCreate, PermOrderRead, PermOrderReadOwn, PermOrderUpdate, PermOrderCancelOwn,
+	PermDeliveryRead, PermDeliveryReadOwn, PermDeliveryAssign, PermDeliveryUpdate, PermDeliveryCourier,
+	PermDeliveryZoneRead, PermDeliveryZoneWrite,
+	PermExchangeRateRead, PermExchangeRateWrite,
+	PermTaxClassRead, PermTaxClassWrite,
+}
+
+// DefaultRolePermissions are seeded for roles that have no stored permissions
+var DefaultRolePermissions = map[string][]string{
+	RoleUser: {
+		PermAccountSelf,
+		PermUserUpdateSelf,
+		PermProductRead,
+		PermCategoryRead,
+		PermCartReadOwn,
+		PermCartWriteOwn,
+		PermOrderCreate,
+		PermOrderReadOwn,
+		PermOrderCancelOwn,
+		PermDeliveryReadOwn,
+	},
+	RoleAdmin: AllPermissions,
+	RoleCourier: {
+		PermAccountSelf,
+		PermDeliveryCourier,
+	},
+}
+
+// ImpersonationBlockedPermissions are withheld from impersonation sessions so
+// support staff can't change credentials or place and cancel orders
+var ImpersonationBlockedPermissions = []string{
+	PermAccountSelf,
+	PermOrderCreate,
+	PermOrderCancelOwn,
+}
+
+// APIKeyBlockedScopes can't be granted to API keys: they would let a key
+// widen its own access (keys, roles, user sessions) or need a user account
+// behind the request (checkout)
+var APIKeyBlockedScopes = []string{
+	PermAPIKeyManage,
+	PermRoleWrite,
+	PermUserImpersonate,
+	PermOrderCreate,
+}
+
+// IsValidPermission checks if a permission is known
+func IsValidPermission(permission string) bool {
+	for _, known := range AllPermissions {
+		if permission == known {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions is the set of permissions granted to a role
+type RolePermissions struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Role        string             `bson:"role"`
+	Permissions []string           `bson:"permissions"`
+	UpdatedAt   primitive.DateTime `bson:"updated_at"`
+}
+
+// IsSelfScopedPermission reports whether a permission only applies to the
+// caller's own resources, which API keys don't have
+func IsSelfScopedPermission(permission string) bool {
+	return strings.HasSuffix(permission, ":own") || strings.HasSuffix(permission, ":self")
+}
@@ -6,8 +6,9 @@ import (
 
 // ProductCategory represents a user document in the database
 type ProductCategory struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	Name      string             `bson:"name"`
-	CreatedAt primitive.DateTime `bson:"created_at"`
-	UpdatedOn primitive.DateTime `bson:"updated_on"`
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Name       string             `bson:"name"`
+	TaxClassID primitive.ObjectID `bson:"tax_class_id,omitempty"` // Tax class of the category's products, the default class when empty
+	CreatedAt  primitive.DateTime `bson:"created_at"`
+	UpdatedOn  primitive.DateTime `bson:"updated_on"`
 }
@@ -8,9 +8,44 @@ import (
 type Product struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty"`
 	Name       string             `bson:"name" binding:"required"`
-	Price      int                `bson:"price" binding:"required"`
+	Price      Money              `bson:"price" binding:"required"` // Default price, used as is by products without variants
 	Detail     string             `bson:"detail"`
+	Stock      int                `bson:"stock"` // Quantity available for sale, only used by products without variants
 	IDCategory primitive.ObjectID `bson:"id_category,omitempty"`
+	Options    []ProductOption    `bson:"options,omitempty"`  // Option axes the variants differ in, e.g. size and colour
+	Variants   []ProductVariant   `bson:"variants,omitempty"` // Sellable variants, one per option combination
 	CreatedAt  primitive.DateTime `bson:"created_at"`
 	UpdatedOn  primitive.DateTime `bson:"updated_on"`
 }
+
+// ProductOption is an option axis of a product and its allowed values
+type ProductOption struct {
+	Name   string   `bson:"name" json:"name"`
+	Values []string `bson:"values" json:"values"`
+}
+
+// ProductVariant is a sellable combination of option values with its own
+// SKU, price and stock
+type ProductVariant struct {
+	ID      primitive.ObjectID `bson:"_id" json:"id"`
+	SKU     string             `bson:"sku" json:"sku"`
+	Barcode string             `bson:"barcode,omitempty" json:"barcode,omitempty"`
+	Options map[string]string  `bson:"options" json:"options"` // Value chosen for each option axis
+	Price   Money              `bson:"price" json:"price"`
+	Stock   int                `bson:"stock" json:"stock"`
+}
+
+// HasVariants reports whether the product is sold through variants
+func (p Product) HasVariants() bool {
+	return len(p.Variants) > 0
+}
+
+// FindVariant returns the variant of the product with the given ID
+func (p Product) FindVariant(id primitive.ObjectID) (ProductVariant, bool) {
+	for _, variant := range p.Variants {
+		if variant.ID == id {
+			return variant, true
+		}
+	}
+	return ProductVariant{}, false
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session represents a logged-in device holding a rotating refresh token
+type Session struct {
+	ID                 primitive.ObjectID `bson:"_id,omitempty"`
+	UserID             primitive.ObjectID `bson:"user_id"`
+	RefreshTokenHash   string             `bson:"refresh_token_hash" json:"-"`   // SHA-256 of the current refresh token
+	RotatedTokenHashes []string           `bson:"rotated_token_hashes" json:"-"` // Hashes of refresh tokens already used, for reuse detection
+	UserAgent          string             `bson:"user_agent"`
+	IP                 string             `bson:"ip"`
+	CreatedAt          primitive.DateTime `bson:"created_at"`
+	LastSeenAt         primitive.DateTime `bson:"last_seen_at"`
+	ExpiresAt          primitive.DateTime `bson:"expires_at"`               // When the refresh token stops working
+	RevokedAt          primitive.DateTime `bson:"revoked_at,omitempty"`     // Set once the session is logged out or revoked
+	RevokedReason      string             `bson:"revoked_reason,omitempty"` // Why the session was revoked
+
+	// Set on sessions an admin started to act as the user
+	ImpersonatorID primitive.ObjectID `bson:"impersonator_id,omitempty"`
+}
+
+// Predefined session revocation reasons
+const (
+	SessionRevokedLogout         = "logout"              // User logged out on the device
+	SessionRevokedByUser         = "revoked_by_user"     // User revoked the device from another session
+	SessionRevokedReuse          = "refresh_token_reuse" // An already rotated refresh token was presented
+	SessionRevokedPasswordChange = "password_change"     // User changed or reset their password
+	SessionRevokedImpersonation  = "impersonation_ended" // Admin ended an impersonation session
+	SessionRevokedUserDeleted    = "user_deleted"        // Admin deleted the user
+)
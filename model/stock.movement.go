@@ -0,0 +1,37 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StockMovement records a change to a product's stock quantity
+type StockMovement struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ProductID primitive.ObjectID `bson:"product_id"`
+	VariantID primitive.ObjectID `bson:"variant_id,omitempty"` // Variant whose stock changed, if any
+	Delta     int                `bson:"delta"`                // Positive adds stock, negative removes it
+	Reason    string             `bson:"reason"`               // One of the StockReason constants
+	OrderID   primitive.ObjectID `bson:"order_id,omitempty"`   // Order that caused the movement, if any
+	ActorID   primitive.ObjectID `bson:"actor_id,omitempty"`   // User who made the change, if any
+	Note      string             `bson:"note,omitempty"`
+	CreatedAt primitive.DateTime `bson:"created_at"`
+}
+
+// Predefined stock movement reason constants
+const (
+	StockReasonRestock       = "restock"        // New stock received
+	StockReasonDamaged       = "damaged"        // Stock written off as damaged or lost
+	StockReasonCorrection    = "correction"     // Manual correction after a stock count
+	StockReasonReturn        = "return"         // Goods returned by a customer
+	StockReasonOrderPlaced   = "order_placed"   // Stock reserved by a placed order
+	StockReasonOrderReleased = "order_released" // Reservation released by a cancelled order
+)
+
+// IsValidStockAdjustmentReason checks if a reason may be used for manual stock adjustments
+func IsValidStockAdjustmentReason(reason string) bool {
+	switch reason {
+	case StockReasonRestock, StockReasonDamaged, StockReasonCorrection, StockReasonReturn:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaxClass is the tax treatment of the products in a category
+type TaxClass struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	Code            string             `bson:"code"` // Stable identifier referenced by the configuration, e.g. "vat7"
+	Name            string             `bson:"name"`
+	RateBasisPoints int                `bson:"rate_basis_points"` // 700 is 7%
+	Exempt          bool               `bson:"exempt"`            // Goods outside the scope of VAT, reported apart from zero-rated goods
+	CreatedAt       primitive.DateTime `bson:"created_at"`
+	UpdatedOn       primitive.DateTime `bson:"updated_on"`
+}
+
+// TaxLine is the tax charged for one tax class
+type TaxLine struct {
+	TaxClass        string `bson:"tax_class" json:"tax_class"` // Code of the tax class
+	Name            string `bson:"name" json:"name"`
+	RateBasisPoints int    `bson:"rate_basis_points" json:"rate_basis_points"`
+	Exempt          bool   `bson:"exempt" json:"exempt"`
+	Net             Money  `bson:"net" json:"net"` // Amount excluding tax
+	Tax             Money  `bson:"tax" json:"tax"`
+}
+
+// Predefined tax class codes
+const (
+	TaxClassVAT7   = "vat7"   // Thai standard VAT
+	TaxClassExempt = "exempt" // VAT exempt goods such as unprocessed food
+)
+
+// DefaultTaxClasses are seeded when no class with their code exists
+var DefaultTaxClasses = []TaxClass{
+	{Code: TaxClassVAT7, Name: "VAT 7%", RateBasisPoints: 700},
+	{Code: TaxClassExempt, Name: "VAT exempt", Exempt: true},
+}
@@ -1,17 +1,20 @@
 package models
 
 import (
+	"encoding/json"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const (
-	RoleUser  = "user"
-	RoleAdmin = "admin"
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleCourier = "courier"
 )
 
 // IsValidRole checks if a role is valid
 func IsValidRole(role string) bool {
-	return role == RoleUser || role == RoleAdmin
+	return role == RoleUser || role == RoleAdmin || role == RoleCourier
 }
 
 // User represents a user document in the database
@@ -19,11 +22,71 @@ type User struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty"`
 	Name         string             `bson:"name"`
 	Email        string             `bson:"email"`
-	Password     string             `bson:"password"`
+	Password     string             `bson:"password" json:"-"`
 	Role         string             `bson:"role"`
-	Token        string             `bson:"token"`
 	CreatedAt    primitive.DateTime `bson:"created_at"`
 	ShippingAddr ShippingAddress    `bson:"shipping_address"`
+
+	// Set for self-registered users until they follow the verification link
+	EmailVerificationPending bool               `bson:"email_verification_pending,omitempty"`
+	EmailVerifiedAt          primitive.DateTime `bson:"email_verified_at,omitempty"`
+
+	// TOTP two-factor authentication
+	TOTPEnabled        bool     `bson:"totp_enabled,omitempty"`
+	TOTPSecret         string   `bson:"totp_secret,omitempty" json:"-"`          // Base32 secret of the enrolled authenticator
+	TOTPPendingSecret  string   `bson:"totp_pending_secret,omitempty" json:"-"`  // Secret awaiting confirmation during enrollment
+	TOTPLastStep       int64    `bson:"totp_last_step,omitempty" json:"-"`       // Last accepted time step, to reject replayed codes
+	RecoveryCodeHashes []string `bson:"recovery_code_hashes,omitempty" json:"-"` // SHA-256 of unused recovery codes
+
+	// Accounts at external OpenID Connect providers linked to this user
+	Identities []UserIdentity `bson:"identities,omitempty"`
+}
+
+// UserIdentity links a user to an account at an OpenID Connect provider
+type UserIdentity struct {
+	Provider string             `bson:"provider"` // Configured provider name, e.g. "google"
+	Subject  string             `bson:"subject"`  // The provider's stable user ID ("sub" claim)
+	Email    string             `bson:"email,omitempty"`
+	LinkedAt primitive.DateTime `bson:"linked_at"`
+}
+
+// UserResponse is how a user is rendered in API responses. It leaves out the
+// password hash, 2FA secrets and anything else that must never be sent.
+type UserResponse struct {
+	ID               string             `json:"id"`
+	Name             string             `json:"name"`
+	Email            string             `json:"email"`
+	Role             string             `json:"role"`
+	CreatedAt        primitive.DateTime `json:"created_at"`
+	ShippingAddr     ShippingAddress    `json:"shipping_address"`
+	EmailVerified    bool               `json:"email_verified"`
+	TwoFactorEnabled bool               `json:"two_factor_enabled"`
+	LinkedProviders  []string           `json:"linked_providers"`
+}
+
+// ToResponse converts the user for output
+func (u User) ToResponse() UserResponse {
+	providers := []string{}
+	for _, identity := range u.Identities {
+		providers = append(providers, identity.Provider)
+	}
+	return UserResponse{
+		ID:               u.ID.Hex(),
+		Name:             u.Name,
+		Email:            u.Email,
+		Role:             u.Role,
+		CreatedAt:        u.CreatedAt,
+		ShippingAddr:     u.ShippingAddr,
+		EmailVerified:    !u.EmailVerificationPending,
+		TwoFactorEnabled: u.TOTPEnabled,
+		LinkedProviders:  providers,
+	}
+}
+
+// MarshalJSON renders a user as its UserResponse, so a User passed straight
+// to a response can't leak its secrets
+func (u User) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.ToResponse())
 }
 
 func (u *User) SetRole(role string) string {
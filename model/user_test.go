@@ -0,0 +1,111 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// secretUserKeys are the field names credentials are stored or rendered under
+var secretUserKeys = []string{
+	"password", "Password",
+	"totp_secret", "TOTPSecret",
+	"totp_pending_secret", "TOTPPendingSecret",
+	"totp_last_step", "TOTPLastStep",
+	"recovery_code_hashes", "RecoveryCodeHashes",
+	"identities", "Identities", "subject", "Subject",
+}
+
+// secretUser has every field filled in, credentials included
+func secretUser() User {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	return User{
+		ID:                       primitive.NewObjectID(),
+		Name:                     "Test User",
+		Email:                    "user@example.com",
+		Password:                 "$2a$10$passwordhashpasswordhashpasswordhashpasswordhash",
+		Role:                     RoleAdmin,
+		CreatedAt:                now,
+		ShippingAddr:             ShippingAddress{Street: "1 Main Road", City: "Bangkok"},
+		EmailVerificationPending: true,
+		EmailVerifiedAt:          now,
+		TOTPEnabled:              true,
+		TOTPSecret:               "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP",
+		TOTPPendingSecret:        "KRSXG5CTMVRXEZLUKRSXG5CTMVRXEZLU",
+		TOTPLastStep:             58201934,
+		RecoveryCodeHashes:       []string{"5d41402abc4b2a76b9719d911017c592"},
+		Identities: []UserIdentity{
+			{Provider: "google", Subject: "provider-subject-1", Email: "user@example.com", LinkedAt: now},
+		},
+	}
+}
+
+func TestUserJSONOmitsSecrets(t *testing.T) {
+	user := secretUser()
+	secretValues := []string{
+		user.Password,
+		user.TOTPSecret,
+		user.TOTPPendingSecret,
+		"58201934",
+		user.RecoveryCodeHashes[0],
+		user.Identities[0].Subject,
+	}
+
+	renderings := map[string]interface{}{
+		"user":          user,
+		"pointer":       &user,
+		"slice":         []User{user},
+		"response body": map[string]interface{}{"user": user},
+		"response":      user.ToResponse(),
+	}
+	for name, value := range renderings {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		body := string(raw)
+
+		var decoded interface{}
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, key := range secretUserKeys {
+			if path, ok := findJSONKey(decoded, key, "$"); ok {
+				t.Errorf("%s contains %s: %s", name, path, body)
+			}
+		}
+		for _, value := range secretValues {
+			if strings.Contains(body, value) {
+				t.Errorf("%s contains the secret %q: %s", name, value, body)
+			}
+		}
+		if !strings.Contains(body, user.Email) || !strings.Contains(body, `"linked_providers":["google"]`) {
+			t.Errorf("%s is missing user fields: %s", name, body)
+		}
+	}
+}
+
+// findJSONKey looks for a key anywhere in decoded JSON, returning its path
+func findJSONKey(value interface{}, key string, path string) (string, bool) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for name, child := range typed {
+			if name == key {
+				return path + "." + name, true
+			}
+			if found, ok := findJSONKey(child, key, path+"."+name); ok {
+				return found, true
+			}
+		}
+	case []interface{}:
+		for _, child := range typed {
+			if found, ok := findJSONKey(child, key, path+"[]"); ok {
+				return found, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,17 @@
+package adminRouter
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	adminController "github.com/wachirawittd123/shop-online-backend-golang/controller/admin"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+)
+
+// RegisterAdminRoutes defines support tooling routes
+func RegisterAdminRoutes(router *gin.Engine) {
+	adminGroup := router.Group("/admin")
+	{
+		adminGroup.POST("/impersonate/:userId", common.RequirePermission(models.PermUserImpersonate), adminController.Impersonate)
+		adminGroup.DELETE("/impersonations/:id", common.RequirePermission(models.PermUserImpersonate), adminController.EndImpersonation)
+	}
+}
@@ -0,0 +1,18 @@
+package apiKeyRouter
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	apiKeyController "github.com/wachirawittd123/shop-online-backend-golang/controller/api_key"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+)
+
+// RegisterAPIKeyRoutes defines API key management routes
+func RegisterAPIKeyRoutes(router *gin.Engine) {
+	apiKeyGroup := router.Group("/api-keys")
+	{
+		apiKeyGroup.GET("/", common.RequirePermission(models.PermAPIKeyManage), apiKeyController.GetAPIKeys)
+		apiKeyGroup.POST("/", common.RequirePermission(models.PermAPIKeyManage), apiKeyController.AddAPIKey)
+		apiKeyGroup.DELETE("/:id", common.RequirePermission(models.PermAPIKeyManage), apiKeyController.RevokeAPIKey)
+	}
+}
@@ -4,13 +4,36 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/wachirawittd123/shop-online-backend-golang/common"
 	authController "github.com/wachirawittd123/shop-online-backend-golang/controller/auth"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
 )
 
 // RegisterUserRoutes defines user-related routes
 func RegisterAuthRoutes(router *gin.Engine) {
+	// Public keys for services verifying our tokens
+	router.GET("/.well-known/jwks.json", authController.GetJWKS)
+
 	authGroup := router.Group("/auth")
 	{
+		authGroup.POST("/register", authController.Register)
+		authGroup.GET("/verify-email", authController.VerifyEmail)
+		authGroup.POST("/resend-verification", authController.ResendVerification)
 		authGroup.POST("/login", authController.Login)
-		authGroup.POST("/logout", common.AuthMiddleware("user", "admin"), authController.Logout)
+		authGroup.POST("/login/2fa", authController.LoginTwoFactor)
+		authGroup.POST("/login/2fa/setup", authController.LoginSetupTwoFactor)
+		authGroup.GET("/oidc/:provider/login", authController.OIDCLogin)
+		authGroup.GET("/oidc/:provider/callback", authController.OIDCCallback)
+		authGroup.POST("/oidc/exchange", authController.OIDCExchange)
+		authGroup.POST("/logout", common.RequirePermission(models.PermAccountSelf), authController.Logout)
+		authGroup.POST("/refresh", authController.Refresh)
+		authGroup.POST("/forgot-password", authController.ForgotPassword)
+		authGroup.POST("/reset-password", authController.ResetPassword)
+		authGroup.POST("/change-password", common.RequirePermission(models.PermAccountSelf), authController.ChangePassword)
+		authGroup.POST("/2fa/setup", common.RequirePermission(models.PermAccountSelf), authController.SetupTwoFactor)
+		authGroup.POST("/2fa/enable", common.RequirePermission(models.PermAccountSelf), authController.EnableTwoFactor)
+		authGroup.POST("/2fa/disable", common.RequirePermission(models.PermAccountSelf), authController.DisableTwoFactor)
+		authGroup.POST("/2fa/recovery-codes", common.RequirePermission(models.PermAccountSelf), authController.RegenerateRecoveryCodes)
+		authGroup.GET("/sessions", common.RequirePermission(models.PermAccountSelf), authController.GetSessions)
+		authGroup.DELETE("/sessions", common.RequirePermission(models.PermAccountSelf), authController.RevokeOtherSessions)
+		authGroup.DELETE("/sessions/:id", common.RequirePermission(models.PermAccountSelf), authController.RevokeSession)
 	}
 }
@@ -4,14 +4,16 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/wachirawittd123/shop-online-backend-golang/common"
 	cartController "github.com/wachirawittd123/shop-online-backend-golang/controller/cart"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
 )
 
 // RegisterCartRoutes Routes defines cart-related routes
 func RegisterCartRoutes(router *gin.Engine) {
 	cartGroup := router.Group("/cart")
 	{
-		cartGroup.GET("/", common.AuthMiddleware("user", "admin"), cartController.GetCart)
-		cartGroup.GET("/:id", common.AuthMiddleware("user", "admin"), cartController.GetCart)
-		cartGroup.PUT("/", common.AuthMiddleware("user", "admin"), cartController.UpdateCart)
+		cartGroup.GET("/", common.RequirePermission(models.PermCartRead, models.PermCartReadOwn), cartController.GetCart)
+		cartGroup.GET("/delivery-quote", common.RequirePermission(models.PermCartReadOwn), cartController.GetDeliveryQuote)
+		cartGroup.GET("/:id", common.RequirePermission(models.PermCartRead, models.PermCartReadOwn), cartController.GetCart)
+		cartGroup.PUT("/", common.RequirePermission(models.PermCartWriteOwn), cartController.UpdateCart)
 	}
 }
@@ -0,0 +1,21 @@
+package courierRouter
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	deliveryController "github.com/wachirawittd123/shop-online-backend-golang/controller/delivery"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+)
+
+// RegisterCourierRoutes defines the courier app routes
+func RegisterCourierRoutes(router *gin.Engine) {
+	courierGroup := router.Group("/courier/deliveries")
+	{
+		courierGroup.GET("/", common.RequirePermission(models.PermDeliveryCourier), deliveryController.GetCourierDeliveries)
+		courierGroup.PUT("/:id/accept", common.RequirePermission(models.PermDeliveryCourier), deliveryController.AcceptDelivery)
+		courierGroup.PUT("/:id/reject", common.RequirePermission(models.PermDeliveryCourier), deliveryController.RejectDelivery)
+		courierGroup.PUT("/:id/picked-up", common.RequirePermission(models.PermDeliveryCourier), deliveryController.MarkDeliveryPickedUp)
+		courierGroup.PUT("/:id/delivered", common.RequirePermission(models.PermDeliveryCourier), deliveryController.MarkDeliveryDelivered)
+		courierGroup.PUT("/:id/failed", common.RequirePermission(models.PermDeliveryCourier), deliveryController.MarkDeliveryFailed)
+	}
+}
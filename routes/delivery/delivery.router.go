@@ -0,0 +1,22 @@
+package deliveryRouter
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	deliveryController "github.com/wachirawittd123/shop-online-backend-golang/controller/delivery"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+)
+
+// RegisterDeliveryRoutes defines delivery-related routes
+func RegisterDeliveryRoutes(router *gin.Engine) {
+	router.GET("/track/:code", deliveryController.TrackDelivery)
+
+	deliveryGroup := router.Group("/deliveries")
+	{
+		deliveryGroup.GET("/", common.RequirePermission(models.PermDeliveryRead), deliveryController.GetDeliveries)
+		deliveryGroup.GET("/me", common.RequirePermission(models.PermDeliveryRead, models.PermDeliveryReadOwn), deliveryController.GetMyDeliveries)
+		deliveryGroup.GET("/:id", common.RequirePermission(models.PermDeliveryRead, models.PermDeliveryReadOwn), deliveryController.GetDelivery)
+		deliveryGroup.PUT("/:id/assign", common.RequirePermission(models.PermDeliveryAssign), deliveryController.AssignDelivery)
+		deliveryGroup.PUT("/:id/status", common.RequirePermission(models.PermDeliveryUpdate), deliveryController.UpdateDeliveryStatus)
+	}
+}
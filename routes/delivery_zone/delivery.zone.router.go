@@ -0,0 +1,19 @@
+package deliveryZoneRouter
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	deliveryZoneController "github.com/wachirawittd123/shop-online-backend-golang/controller/delivery_zone"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+)
+
+// RegisterDeliveryZoneRoutes defines delivery-zone-related routes
+func RegisterDeliveryZoneRoutes(router *gin.Engine) {
+	deliveryZoneGroup := router.Group("/delivery-zones")
+	{
+		deliveryZoneGroup.GET("/", common.RequirePermission(models.PermDeliveryZoneRead), deliveryZoneController.GetDeliveryZones)
+		deliveryZoneGroup.POST("/", common.RequirePermission(models.PermDeliveryZoneWrite), deliveryZoneController.AddDeliveryZone)
+		deliveryZoneGroup.PUT("/:id", common.RequirePermission(models.PermDeliveryZoneWrite), deliveryZoneController.UpdateDeliveryZone)
+		deliveryZoneGroup.DELETE("/:id", common.RequirePermission(models.PermDeliveryZoneWrite), deliveryZoneController.RemoveDeliveryZone)
+	}
+}
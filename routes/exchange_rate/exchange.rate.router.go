@@ -0,0 +1,19 @@
+package exchangeRateRouter
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	exchangeRateController "github.com/wachirawittd123/shop-online-backend-golang/controller/exchange_rate"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+)
+
+// RegisterExchangeRateRoutes defines exchange rate management routes
+func RegisterExchangeRateRoutes(router *gin.Engine) {
+	exchangeRateGroup := router.Group("/exchange-rates")
+	{
+		exchangeRateGroup.GET("/", common.RequirePermission(models.PermExchangeRateRead), exchangeRateController.GetExchangeRates)
+		exchangeRateGroup.POST("/", common.RequirePermission(models.PermExchangeRateWrite), exchangeRateController.AddExchangeRate)
+		exchangeRateGroup.POST("/import", common.RequirePermission(models.PermExchangeRateWrite), exchangeRateController.ImportExchangeRates)
+		exchangeRateGroup.DELETE("/:id", common.RequirePermission(models.PermExchangeRateWrite), exchangeRateController.RemoveExchangeRate)
+	}
+}
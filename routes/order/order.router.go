@@ -0,0 +1,20 @@
+package orderRouter
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	orderController "github.com/wachirawittd123/shop-online-backend-golang/controller/order"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+)
+
+// RegisterOrderRoutes defines checkout and order-related routes
+func RegisterOrderRoutes(router *gin.Engine) {
+	router.POST("/checkout", common.RequirePermission(models.PermOrderCreate), orderController.Checkout)
+
+	orderGroup := router.Group("/orders")
+	{
+		orderGroup.GET("/", common.RequirePermission(models.PermOrderRead, models.PermOrderReadOwn), orderController.GetOrders)
+		orderGroup.GET("/:id", common.RequirePermission(models.PermOrderRead, models.PermOrderReadOwn), orderController.GetOrder)
+		orderGroup.PUT("/:id/status", common.RequirePermission(models.PermOrderUpdate, models.PermOrderCancelOwn), orderController.UpdateOrderStatus)
+	}
+}
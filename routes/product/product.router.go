@@ -4,16 +4,19 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/wachirawittd123/shop-online-backend-golang/common"
 	productController "github.com/wachirawittd123/shop-online-backend-golang/controller/product"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
 )
 
 // RegisterProductRoutes defines product-related routes
 func RegisterProductRoutes(router *gin.Engine) {
 	productGroup := router.Group("/products")
 	{
-		productGroup.GET("/", common.AuthMiddleware("user", "admin"), productController.GetProducts)
-		productGroup.GET("/:id", common.AuthMiddleware("user", "admin"), productController.GetProduct)
-		productGroup.POST("/", common.AuthMiddleware("admin"), productController.AddProduct)
-		productGroup.PUT("/:id", common.AuthMiddleware("admin"), productController.UpdateProduct)
-		productGroup.DELETE("/:id", common.AuthMiddleware("admin"), productController.RemoveProduct)
+		productGroup.GET("/", common.RequirePermission(models.PermProductRead), productController.GetProducts)
+		productGroup.GET("/:id", common.RequirePermission(models.PermProductRead), productController.GetProduct)
+		productGroup.POST("/", common.RequirePermission(models.PermProductWrite), productController.AddProduct)
+		productGroup.PUT("/:id", common.RequirePermission(models.PermProductWrite), productController.UpdateProduct)
+		productGroup.DELETE("/:id", common.RequirePermission(models.PermProductWrite), productController.RemoveProduct)
+		productGroup.POST("/:id/stock", common.RequirePermission(models.PermInventoryWrite), productController.AdjustStock)
+		productGroup.GET("/:id/stock-movements", common.RequirePermission(models.PermInventoryRead), productController.GetStockMovements)
 	}
 }
@@ -0,0 +1,17 @@
+package roleRouter
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	roleController "github.com/wachirawittd123/shop-online-backend-golang/controller/role"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+)
+
+// RegisterRoleRoutes defines role and permission management routes
+func RegisterRoleRoutes(router *gin.Engine) {
+	roleGroup := router.Group("/roles")
+	{
+		roleGroup.GET("/", common.RequirePermission(models.PermRoleRead), roleController.GetRoles)
+		roleGroup.PUT("/:role/permissions", common.RequirePermission(models.PermRoleWrite), roleController.UpdateRolePermissions)
+	}
+}
@@ -2,10 +2,19 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	adminRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/admin"
+	apiKeyRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/api_key"
 	authRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/auth"
 	cartRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/cart"
+	courierRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/courier"
+	deliveryRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/delivery"
+	deliveryZoneRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/delivery_zone"
+	exchangeRateRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/exchange_rate"
+	orderRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/order"
 	productRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/product"
 	productCategoryRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/product_category"
+	roleRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/role"
+	taxClassRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/tax_class"
 	userRouter "github.com/wachirawittd123/shop-online-backend-golang/routes/user"
 )
 
@@ -17,4 +26,13 @@ func RegisterRoutes(router *gin.Engine) {
 	productRouter.RegisterProductRoutes(router)
 	productCategoryRouter.RegisterProductCategoryRoutes(router)
 	cartRouter.RegisterCartRoutes(router)
+	orderRouter.RegisterOrderRoutes(router)
+	deliveryRouter.RegisterDeliveryRoutes(router)
+	courierRouter.RegisterCourierRoutes(router)
+	deliveryZoneRouter.RegisterDeliveryZoneRoutes(router)
+	roleRouter.RegisterRoleRoutes(router)
+	apiKeyRouter.RegisterAPIKeyRoutes(router)
+	adminRouter.RegisterAdminRoutes(router)
+	exchangeRateRouter.RegisterExchangeRateRoutes(router)
+	taxClassRouter.RegisterTaxClassRoutes(router)
 }
@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// Credentials stored on the listed users. The response may not contain them.
+const (
+	testPasswordHash = "$2a$10$passwordhashpasswordhashpasswordhashpasswordhash"
+	testTOTPSecret   = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+)
+
+// TestListUsersOmitsSecrets lists stored users through the router, from the
+// bearer token to the rendered response. What a user renders to is covered
+// by the model tests; this checks the route doesn't bypass it.
+func TestListUsersOmitsSecrets(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("list users", func(mt *mtest.T) {
+		gin.SetMode(gin.TestMode)
+		common.AppConfig = &common.Config{AppEnv: "dev", AccessTokenTTL: 15 * time.Minute}
+		common.DB = mt.DB
+		common.InitJWTKeys()
+		common.TokenBlacklist = common.NewMemoryTokenBlacklist()
+		common.InvalidatePermissionCache()
+
+		router := gin.New()
+		RegisterRoutes(router)
+
+		token, err := common.GenerateToken(primitive.NewObjectID().Hex(), models.RoleAdmin, primitive.NewObjectID().Hex())
+		if err != nil {
+			mt.Fatal(err)
+		}
+		user := bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "name", Value: "Test User"},
+			{Key: "email", Value: "user@example.com"},
+			{Key: "password", Value: testPasswordHash},
+			{Key: "role", Value: models.RoleUser},
+			{Key: "totp_enabled", Value: true},
+			{Key: "totp_secret", Value: testTOTPSecret},
+		}
+
+		// Mocked replies are consumed in order: authorizing the token, then the listing
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "shop-online.sessions", mtest.FirstBatch, bson.D{{Key: "n", Value: int32(1)}}),
+			mtest.CreateCursorResponse(0, "shop-online.users", mtest.FirstBatch, bson.D{{Key: "n", Value: int32(1)}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: int32(1)}),
+			mtest.CreateCursorResponse(0, "shop-online.role_permissions", mtest.FirstBatch, bson.D{
+				{Key: "role", Value: models.RoleAdmin},
+				{Key: "permissions", Value: bson.A{models.PermUserRead}},
+			}),
+			mtest.CreateCursorResponse(0, "shop-online.users", mtest.FirstBatch, user),
+		)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		body := recorder.Body.String()
+		if recorder.Code != http.StatusOK || !strings.Contains(body, "user@example.com") {
+			mt.Fatalf("status %d: %s", recorder.Code, body)
+		}
+		for _, secret := range []string{`"password"`, testPasswordHash, `"totp_secret"`, testTOTPSecret} {
+			if strings.Contains(body, secret) {
+				mt.Errorf("response contains %s: %s", secret, body)
+			}
+		}
+	})
+}
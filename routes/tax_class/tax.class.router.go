@@ -0,0 +1,19 @@
+package taxClassRouter
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wachirawittd123/shop-online-backend-golang/common"
+	taxClassController "github.com/wachirawittd123/shop-online-backend-golang/controller/tax_class"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
+)
+
+// RegisterTaxClassRoutes defines tax class management routes
+func RegisterTaxClassRoutes(router *gin.Engine) {
+	taxClassGroup := router.Group("/tax-classes")
+	{
+		taxClassGroup.GET("/", common.RequirePermission(models.PermTaxClassRead), taxClassController.GetTaxClasses)
+		taxClassGroup.POST("/", common.RequirePermission(models.PermTaxClassWrite), taxClassController.AddTaxClass)
+		taxClassGroup.PUT("/:id", common.RequirePermission(models.PermTaxClassWrite), taxClassController.UpdateTaxClass)
+		taxClassGroup.DELETE("/:id", common.RequirePermission(models.PermTaxClassWrite), taxClassController.RemoveTaxClass)
+	}
+}
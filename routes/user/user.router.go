@@ -4,15 +4,18 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/wachirawittd123/shop-online-backend-golang/common"
 	userController "github.com/wachirawittd123/shop-online-backend-golang/controller/user"
+	models "github.com/wachirawittd123/shop-online-backend-golang/model"
 )
 
 // RegisterUserRoutes defines user-related routes
 func RegisterUserRoutes(router *gin.Engine) {
 	userGroup := router.Group("/users")
 	{
-		userGroup.GET("/", common.AuthMiddleware("admin"), userController.GetUsers)
-		userGroup.POST("/", common.AuthMiddleware("admin"), userController.AddUser)
-		userGroup.PUT("/:id", common.AuthMiddleware("user", "admin"), userController.UpdateUser)
-		userGroup.DELETE("/:id", common.AuthMiddleware("admin"), userController.RemoveUser)
+		userGroup.GET("/", common.RequirePermission(models.PermUserRead), userController.GetUsers)
+		userGroup.POST("/", common.RequirePermission(models.PermUserWrite), userController.AddUser)
+		userGroup.PUT("/:id", common.RequirePermission(models.PermUserWrite, models.PermUserUpdateSelf), userController.UpdateUser)
+		userGroup.DELETE("/:id", common.RequirePermission(models.PermUserWrite), userController.RemoveUser)
+		userGroup.POST("/:id/unlock", common.RequirePermission(models.PermUserWrite), userController.UnlockUser)
+		userGroup.GET("/:id/auth-events", common.RequirePermission(models.PermUserRead), userController.GetUserAuthEvents)
 	}
 }